@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"kite/src/types"
+)
+
+var appendMu sync.Mutex
+
+// Append writes entry as one JSON line to the audit log in schemaDir.
+func Append(schemaDir string, entry types.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %v", err)
+	}
+	line = append(line, '\n')
+
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	f, err := os.OpenFile(filepath.Join(schemaDir, LogFileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return f.Close()
+}
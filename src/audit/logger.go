@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kite/src/types"
+)
+
+// Logger appends audit entries to the log of the entry's schema under a
+// database root.
+type Logger struct {
+	root string
+}
+
+func NewLogger(dbRoot string) *Logger {
+	return &Logger{root: dbRoot}
+}
+
+// Log stamps entry with the current time, unless it has one, and appends it
+// to <root>/<schemaName>/.audit.log.
+func (l *Logger) Log(schemaName string, entry types.AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	return Append(filepath.Join(l.root, schemaName), entry)
+}
+
+// Read returns the entries of the audit log in schemaDir, including those
+// in rotated logs, oldest first. An empty collection matches every entry, as
+// does a zero since.
+func Read(schemaDir, collection string, since time.Time) ([]types.AuditEntry, error) {
+	if _, err := os.Stat(schemaDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	rotated, err := ListRotatedLogs(schemaDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []types.AuditEntry
+	for i := len(rotated) - 1; i >= 0; i-- {
+		if entries, err = readLog(rotated[i].Path, collection, since, entries); err != nil {
+			return nil, err
+		}
+	}
+	return readLog(filepath.Join(schemaDir, LogFileName), collection, since, entries)
+}
+
+// readLog appends the matching entries of the log at path to entries. A
+// missing log has none.
+func readLog(path, collection string, since time.Time, entries []types.AuditEntry) ([]types.AuditEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry types.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %v", filepath.Base(path), line, err)
+		}
+		if collection != "" && entry.Collection != collection {
+			continue
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return entries, nil
+}
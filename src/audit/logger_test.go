@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kite/src/types"
+)
+
+func TestReadIncludesRotatedLogs(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, op := range []string{"add", "insert", "edit"} {
+		if err := Append(dir, types.AuditEntry{Time: start.Add(time.Duration(i) * time.Hour), Operation: op, Collection: "notes"}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			break
+		}
+		// Rotate after each entry but the last, as RotateIfNeeded would.
+		rotated := filepath.Join(dir, LogFileName+"."+start.Add(time.Duration(i)*time.Hour).Format(rotatedTimeFormat))
+		if err := os.Rename(filepath.Join(dir, LogFileName), rotated); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Read(dir, "notes", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, entry := range entries {
+		ops = append(ops, entry.Operation)
+	}
+	if len(ops) != 3 || ops[0] != "add" || ops[1] != "insert" || ops[2] != "edit" {
+		t.Errorf("operations = %v, want [add insert edit]", ops)
+	}
+
+	entries, err = Read(dir, "notes", start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("read %d entries since the second, want 2", len(entries))
+	}
+}
+
+func TestReadMissingSchema(t *testing.T) {
+	entries, err := Read(filepath.Join(t.TempDir(), "missing"), "", time.Time{})
+	if err != nil || entries != nil {
+		t.Errorf("Read = %v, %v, want no entries and no error", entries, err)
+	}
+}
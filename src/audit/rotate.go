@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"kite/src/types"
+)
+
+const (
+	LogFileName = ".audit.log"
+
+	DefaultMaxSizeMB  = 100
+	DefaultMaxAgeDays = 30
+	DefaultMaxFiles   = 5
+
+	rotatedTimeFormat = "20060102T150405Z"
+)
+
+// RotateIfNeeded renames logPath to <logPath>.<timestamp> once it exceeds
+// maxSizeMB and starts a fresh, empty log. Rotated logs older than
+// maxAgeDays are deleted.
+func RotateIfNeeded(logPath string, maxSizeMB int, maxAgeDays int) error {
+	info, err := os.Stat(logPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat audit log: %v", err)
+	}
+
+	if err == nil && maxSizeMB > 0 && info.Size() > int64(maxSizeMB)*1024*1024 {
+		rotated := logPath + "." + time.Now().UTC().Format(rotatedTimeFormat)
+		if err := os.Rename(logPath, rotated); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %v", err)
+		}
+		if err := os.WriteFile(logPath, nil, 0600); err != nil {
+			return fmt.Errorf("failed to create audit log: %v", err)
+		}
+	}
+
+	if maxAgeDays <= 0 {
+		return nil
+	}
+	logs, err := ListRotatedLogs(filepath.Dir(logPath))
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().AddDate(0, 0, -maxAgeDays)
+	for _, log := range logs {
+		info, err := os.Stat(log.Path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(log.Path); err != nil {
+				return fmt.Errorf("failed to delete old audit log: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// PruneRotatedLogs keeps only the maxFiles most recently rotated logs.
+func PruneRotatedLogs(schemaDir string, maxFiles int) error {
+	if maxFiles <= 0 {
+		return nil
+	}
+	logs, err := ListRotatedLogs(schemaDir)
+	if err != nil {
+		return err
+	}
+	for i := maxFiles; i < len(logs); i++ {
+		if err := os.Remove(logs[i].Path); err != nil {
+			return fmt.Errorf("failed to delete old audit log: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListRotatedLogs returns the rotated audit logs in schemaDir, newest first.
+func ListRotatedLogs(schemaDir string) ([]types.AuditLogMeta, error) {
+	entries, err := os.ReadDir(schemaDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema directory: %v", err)
+	}
+
+	var logs []types.AuditLogMeta
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), LogFileName+".")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		rotatedAt, err := time.Parse(rotatedTimeFormat, suffix)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, types.AuditLogMeta{
+			Name:      entry.Name(),
+			Path:      filepath.Join(schemaDir, entry.Name()),
+			SizeBytes: info.Size(),
+			RotatedAt: rotatedAt,
+		})
+	}
+
+	sort.Slice(logs, func(i, j int) bool { return logs[i].RotatedAt.After(logs[j].RotatedAt) })
+	return logs, nil
+}
+
+// StartRotation rotates the audit log of every schema under dbDir now and
+// then once per interval.
+func StartRotation(dbDir string, interval time.Duration, maxSizeMB, maxAgeDays, maxFiles int) {
+	run := func() {
+		dirs := []string{dbDir}
+		if entries, err := os.ReadDir(dbDir); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+					dirs = append(dirs, filepath.Join(dbDir, entry.Name()))
+				}
+			}
+		}
+		for _, dir := range dirs {
+			if err := RotateIfNeeded(filepath.Join(dir, LogFileName), maxSizeMB, maxAgeDays); err != nil {
+				fmt.Fprintf(os.Stderr, "Audit log rotation failed in %s: %v\n", dir, err)
+				continue
+			}
+			if err := PruneRotatedLogs(dir, maxFiles); err != nil {
+				fmt.Fprintf(os.Stderr, "Audit log pruning failed in %s: %v\n", dir, err)
+			}
+		}
+	}
+
+	run()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"kite/src/types"
+)
+
+const DefaultCapacity = 50
+
+type cacheEntry struct {
+	key     string
+	records []types.Record
+	etag    string
+}
+
+// CollectionCache keeps the decrypted records of the most recently read
+// collections, keyed by collection and validated by an etag of the
+// encrypted file.
+type CollectionCache struct {
+	capacity int
+	mu       sync.RWMutex
+	items    map[string]*list.Element
+	lruList  *list.List
+}
+
+func New(capacity int) *CollectionCache {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &CollectionCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		lruList:  list.New(),
+	}
+}
+
+// Get returns the cached records for key if they were stored with etag.
+// The returned slice is a copy; the records themselves are shared and must
+// not be modified.
+func (c *CollectionCache) Get(key, etag string) ([]types.Record, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if entry.etag != etag {
+		return nil, false
+	}
+	c.lruList.MoveToFront(elem)
+	return append([]types.Record(nil), entry.records...), true
+}
+
+// Peek returns the cached records for key whatever etag they were stored
+// with, for reads that accept stale data.
+func (c *CollectionCache) Peek(key string) ([]types.Record, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.lruList.MoveToFront(elem)
+	return append([]types.Record(nil), elem.Value.(*cacheEntry).records...), true
+}
+
+// Set stores records for key, evicting the least recently used collection
+// when the cache is full.
+func (c *CollectionCache) Set(key, etag string, records []types.Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records = append([]types.Record(nil), records...)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.records, entry.etag = records, etag
+		c.lruList.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.lruList.PushFront(&cacheEntry{key: key, records: records, etag: etag})
+	if c.lruList.Len() > c.capacity {
+		oldest := c.lruList.Back()
+		c.lruList.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *CollectionCache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.lruList.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+func (c *CollectionCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lruList.Len()
+}
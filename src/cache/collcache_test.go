@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestCollectionCache(t *testing.T) {
+	c := New(2)
+	c.Set("a", "v1", []types.Record{{"_id": "1"}})
+	if records, ok := c.Get("a", "v1"); !ok || len(records) != 1 {
+		t.Fatalf("Get(a, v1) = %v, %v", records, ok)
+	}
+	if _, ok := c.Get("a", "v2"); ok {
+		t.Error("Get returned records stored under another etag")
+	}
+
+	c.Set("b", "v1", nil)
+	c.Get("a", "v1")
+	c.Set("c", "v1", nil)
+	if _, ok := c.Get("b", "v1"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	if _, ok := c.Get("a", "v1"); !ok || c.Len() != 2 {
+		t.Errorf("a evicted or cache holds %d entries, want a kept and 2 entries", c.Len())
+	}
+
+	c.Invalidate("a")
+	if _, ok := c.Peek("a"); ok {
+		t.Error("Peek found an invalidated entry")
+	}
+}
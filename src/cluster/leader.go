@@ -0,0 +1,203 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"kite/src/filelock"
+	"kite/src/types"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HeartbeatInterval = 5 * time.Second
+	// LeaderTimeout is how old a heartbeat may get before the leader is
+	// considered dead and another instance may take over.
+	LeaderTimeout = 15 * time.Second
+)
+
+// Elector takes part in leader election through a .leader file in the
+// database directory that all instances share.
+type Elector struct {
+	path       string
+	instanceID string
+	host       string
+	url        string
+
+	mu     sync.Mutex
+	leader bool
+}
+
+// NewElector returns an elector for the database at dbDir. url is how other
+// instances tell clients to reach this one if it becomes leader.
+func NewElector(dbDir, url string) *Elector {
+	host, _ := os.Hostname()
+	return &Elector{
+		path:       filepath.Join(dbDir, ".leader"),
+		instanceID: uuid.New().String(),
+		host:       host,
+		url:        url,
+	}
+}
+
+func (e *Elector) InstanceID() string {
+	return e.instanceID
+}
+
+// IsLeader reports whether this instance won the last election or
+// heartbeat.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	e.leader = leader
+	e.mu.Unlock()
+}
+
+// Leader returns the current contents of the leader file.
+func (e *Elector) Leader() (types.LeaderInfo, error) {
+	var info types.LeaderInfo
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("failed to parse leader file: %v", err)
+	}
+	return info, nil
+}
+
+// Stale reports whether info's heartbeat is older than LeaderTimeout.
+func Stale(info types.LeaderInfo, now time.Time) bool {
+	heartbeat, err := time.Parse(time.RFC3339Nano, info.Heartbeat)
+	return err != nil || now.Sub(heartbeat) > LeaderTimeout
+}
+
+// Elect tries to become leader. The leader file is created exclusively, so
+// only one instance can win; a leader whose heartbeat has gone stale is
+// replaced.
+func (e *Elector) Elect() (bool, error) {
+	unlock, err := filelock.Lock(e.path + ".lock")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	info, err := e.Leader()
+	switch {
+	case err == nil && info.InstanceID == e.instanceID:
+		e.setLeader(true)
+		return true, nil
+	case err == nil && !Stale(info, time.Now()):
+		e.setLeader(false)
+		return false, nil
+	case err == nil || !os.IsNotExist(err):
+		// A dead leader or an unreadable file: clear it and stand.
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to remove stale leader file: %v", err)
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	info = types.LeaderInfo{InstanceID: e.instanceID, Host: e.host, URL: e.url, ElectedAt: now, Heartbeat: now}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal leader file: %v", err)
+	}
+	f, err := os.OpenFile(e.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		e.setLeader(false)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create leader file: %v", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(e.path)
+		return false, fmt.Errorf("failed to write leader file: %v", err)
+	}
+	e.setLeader(true)
+	return true, nil
+}
+
+// Heartbeat refreshes the leader file if this instance still owns it and
+// steps down if another instance has taken over.
+func (e *Elector) Heartbeat() error {
+	unlock, err := filelock.Lock(e.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	info, err := e.Leader()
+	if err != nil || info.InstanceID != e.instanceID {
+		e.setLeader(false)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	info.Heartbeat = time.Now().UTC().Format(time.RFC3339Nano)
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal leader file: %v", err)
+	}
+	if err := os.WriteFile(e.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write leader file: %v", err)
+	}
+	return nil
+}
+
+// Start runs an election and then, every HeartbeatInterval, either refreshes
+// the heartbeat or, as a follower, stands again if the leader has died.
+func (e *Elector) Start() {
+	if _, err := e.Elect(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: leader election failed: %v\n", err)
+	}
+	go func() {
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			var err error
+			if e.IsLeader() {
+				err = e.Heartbeat()
+			} else {
+				_, err = e.Elect()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: leader election failed: %v\n", err)
+			}
+		}
+	}()
+}
+
+// Resign removes the leader file if this instance holds it, so a follower
+// can take over without waiting for the timeout.
+func (e *Elector) Resign() error {
+	unlock, err := filelock.Lock(e.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	e.setLeader(false)
+	if info, err := e.Leader(); err == nil && info.InstanceID == e.instanceID {
+		if err := os.Remove(e.path); err != nil {
+			return fmt.Errorf("failed to remove leader file: %v", err)
+		}
+	}
+	return nil
+}
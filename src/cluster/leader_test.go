@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kite/src/types"
+)
+
+func TestElectionAfterLeaderDies(t *testing.T) {
+	dir := t.TempDir()
+	a := NewElector(dir, "http://a:4141")
+	b := NewElector(dir, "http://b:4141")
+
+	if won, err := a.Elect(); err != nil || !won {
+		t.Fatalf("first election = %v, %v; want a to win", won, err)
+	}
+	if won, err := b.Elect(); err != nil || won || b.IsLeader() {
+		t.Fatalf("second election = %v, %v; want b to lose while a is alive", won, err)
+	}
+	if err := a.Heartbeat(); err != nil || !a.IsLeader() {
+		t.Fatalf("heartbeat = %v, leader %v", err, a.IsLeader())
+	}
+
+	// a stops beating: its heartbeat is older than LeaderTimeout.
+	path := filepath.Join(dir, ".leader")
+	info, err := a.Leader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Heartbeat = time.Now().Add(-LeaderTimeout - time.Second).UTC().Format(time.RFC3339Nano)
+	data, _ := json.Marshal(info)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if won, err := b.Elect(); err != nil || !won {
+		t.Fatalf("election after a died = %v, %v; want b to win", won, err)
+	}
+	if leader, err := b.Leader(); err != nil || leader.InstanceID != b.InstanceID() || leader.URL != "http://b:4141" {
+		t.Errorf("leader file = %+v, %v; want b", leader, err)
+	}
+	// a comes back and finds it has been replaced.
+	if err := a.Heartbeat(); err != nil || a.IsLeader() {
+		t.Errorf("old leader heartbeat = %v, leader %v; want it to step down", err, a.IsLeader())
+	}
+
+	if err := b.Resign(); err != nil || b.IsLeader() {
+		t.Fatalf("resign = %v, leader %v", err, b.IsLeader())
+	}
+	if won, err := a.Elect(); err != nil || !won {
+		t.Errorf("election after b resigned = %v, %v; want a to win", won, err)
+	}
+}
+
+func TestStale(t *testing.T) {
+	now := time.Now()
+	fresh := now.Add(-time.Second).Format(time.RFC3339Nano)
+	old := now.Add(-LeaderTimeout - time.Second).Format(time.RFC3339Nano)
+	for heartbeat, want := range map[string]bool{fresh: false, old: true, "": true, "yesterday": true} {
+		if got := Stale(types.LeaderInfo{Heartbeat: heartbeat}, now); got != want {
+			t.Errorf("Stale(heartbeat %q) = %v, want %v", heartbeat, got, want)
+		}
+	}
+}
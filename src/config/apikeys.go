@@ -0,0 +1,109 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"kite/src/types"
+)
+
+// HashAPIKey returns the hex SHA-256 of key, as stored in api_keys.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// updateFile rewrites one top-level field of the config file at path,
+// leaving the others as they are.
+func updateFile(path, field string, value interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to parse config: %v", err)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", field, err)
+	}
+	fields[field] = raw
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write config: %v", err)
+	}
+	return nil
+}
+
+func readAPIKeys(path string) ([]types.APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	var cfg types.DBConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	return cfg.APIKeys, nil
+}
+
+// ListAPIKeys returns the API keys in the config file at path.
+func ListAPIKeys(path string) ([]types.APIKey, error) {
+	return readAPIKeys(path)
+}
+
+// AddAPIKey generates a key, stores its hash under label in the config
+// file at path and returns the key.
+func AddAPIKey(path, label string) (string, error) {
+	if label == "" {
+		return "", fmt.Errorf("label is required")
+	}
+	keys, err := readAPIKeys(path)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range keys {
+		if key.Label == label {
+			return "", fmt.Errorf("an API key labelled %s already exists", label)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate key: %v", err)
+	}
+	key := "kite_" + hex.EncodeToString(secret)
+	keys = append(keys, types.APIKey{
+		Label:     label,
+		Hash:      HashAPIKey(key),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err := updateFile(path, "api_keys", keys); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// RevokeAPIKey removes the API key labelled label from the config file at
+// path.
+func RevokeAPIKey(path, label string) error {
+	keys, err := readAPIKeys(path)
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if key.Label == label {
+			return updateFile(path, "api_keys", append(keys[:i], keys[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no API key labelled %s", label)
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// ParseLogLevel maps a log_level setting to a slog level; empty means info.
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch level {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("%q must be debug, info, warn or error", level)
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	DefaultFilePermission os.FileMode = 0600
+	DefaultDirPermission  os.FileMode = 0700
+)
+
+// ParsePermission reads an octal mode such as "0640". An empty string means
+// fallback.
+func ParsePermission(perm string, fallback os.FileMode) (os.FileMode, error) {
+	if perm == "" {
+		return fallback, nil
+	}
+	mode, err := strconv.ParseUint(perm, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid permission %q: must be an octal mode such as 0640", perm)
+	}
+	return os.FileMode(mode), nil
+}
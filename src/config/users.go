@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"kite/src/types"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func readUsers(path string) ([]types.User, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	var cfg types.DBConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	return cfg.Users, nil
+}
+
+// ListUsers returns the login users in the config file at path.
+func ListUsers(path string) ([]types.User, error) {
+	return readUsers(path)
+}
+
+// AddUser stores a login user with a bcrypt hash of password in the config
+// file at path.
+func AddUser(path string, user types.User, password string) error {
+	if user.Username == "" || password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+	users, err := readUsers(path)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Username == user.Username {
+			return fmt.Errorf("user %s already exists", user.Username)
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+	user.PasswordHash = string(hash)
+	return updateFile(path, "users", append(users, user))
+}
+
+// RemoveUser deletes a login user from the config file at path.
+func RemoveUser(path, username string) error {
+	users, err := readUsers(path)
+	if err != nil {
+		return err
+	}
+	for i, u := range users {
+		if u.Username == username {
+			return updateFile(path, "users", append(users[:i], users[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no user %s", username)
+}
+
+// Authenticate returns the user in users with username and password.
+func Authenticate(users []types.User, username, password string) (types.User, bool) {
+	for _, u := range users {
+		if u.Username == username {
+			if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil {
+				return u, true
+			}
+			return types.User{}, false
+		}
+	}
+	return types.User{}, false
+}
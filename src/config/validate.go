@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"kite/src/types"
+)
+
+var schemaNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// check validates one config field and returns a message when it is invalid.
+type check struct {
+	field string
+	fn    func(cfg types.DBConfig) string
+}
+
+var checks = []check{
+	{"connection_string", func(cfg types.DBConfig) string {
+		if cfg.ConnectionString == "" {
+			return ""
+		}
+		if _, err := ParseConnectionString(cfg.ConnectionString); err != nil {
+			return err.Error()
+		}
+		return ""
+	}},
+	{"username", func(cfg types.DBConfig) string { return required(cfg.Username) }},
+	{"password", func(cfg types.DBConfig) string { return required(cfg.Password) }},
+	{"host", func(cfg types.DBConfig) string { return required(cfg.Host) }},
+	{"port", func(cfg types.DBConfig) string { return validPort(cfg.Port, true) }},
+	{"schema_name", func(cfg types.DBConfig) string {
+		if cfg.SchemaName == "" {
+			return "is required"
+		}
+		if !schemaNamePattern.MatchString(cfg.SchemaName) {
+			return fmt.Sprintf("%q may only contain letters, digits and underscores", cfg.SchemaName)
+		}
+		return ""
+	}},
+	{"api_version", func(cfg types.DBConfig) string {
+		switch cfg.APIVersion {
+		case "", "v1", "v2":
+			return ""
+		}
+		return fmt.Sprintf("%q must be v1 or v2", cfg.APIVersion)
+	}},
+	{"replica_db_dir", func(cfg types.DBConfig) string {
+		if cfg.ReplicaDBDir == "" {
+			return ""
+		}
+		if info, err := os.Stat(cfg.ReplicaDBDir); err != nil || !info.IsDir() {
+			return fmt.Sprintf("%s is not a directory", cfg.ReplicaDBDir)
+		}
+		return ""
+	}},
+	{"web_password", func(cfg types.DBConfig) string {
+		if (cfg.WebUsername == "") != (cfg.WebPassword == "") {
+			return "web_username and web_password must be set together"
+		}
+		return ""
+	}},
+	{"audit_log_max_size_mb", func(cfg types.DBConfig) string { return nonNegative(cfg.AuditLogMaxSizeMB) }},
+	{"audit_log_max_age_days", func(cfg types.DBConfig) string { return nonNegative(cfg.AuditLogMaxAgeDays) }},
+	{"audit_max_files", func(cfg types.DBConfig) string { return nonNegative(cfg.AuditMaxFiles) }},
+	{"rate_limit_rps", func(cfg types.DBConfig) string {
+		if cfg.RateLimitRPS < 0 {
+			return "must not be negative"
+		}
+		return ""
+	}},
+	{"rate_limit_burst", func(cfg types.DBConfig) string { return nonNegative(cfg.RateLimitBurst) }},
+	{"expiry_check_interval", func(cfg types.DBConfig) string { return positiveDuration(cfg.ExpiryCheckInterval) }},
+	{"subscription_ttl", func(cfg types.DBConfig) string { return positiveDuration(cfg.SubscriptionTTL) }},
+	{"lock_timeout", func(cfg types.DBConfig) string { return positiveDuration(cfg.LockTimeout) }},
+	{"undo_queue_size", func(cfg types.DBConfig) string { return nonNegative(cfg.UndoQueueSize) }},
+	{"history_snapshots", func(cfg types.DBConfig) string { return nonNegativePtr(cfg.HistorySnapshots) }},
+	{"file_permission", func(cfg types.DBConfig) string { return validPermission(cfg.FilePermission) }},
+	{"dir_permission", func(cfg types.DBConfig) string { return validPermission(cfg.DirPermission) }},
+	{"debug_port", func(cfg types.DBConfig) string { return validPort(cfg.DebugPort, false) }},
+	{"stale_threshold_days", func(cfg types.DBConfig) string { return nonNegativePtr(cfg.StaleThresholdDays) }},
+	{"reducer_plugins", func(cfg types.DBConfig) string {
+		for _, path := range cfg.ReducerPlugins {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Sprintf("plugin %s does not exist", path)
+			}
+		}
+		return ""
+	}},
+	{"cache_capacity", func(cfg types.DBConfig) string { return nonNegative(cfg.CacheCapacity) }},
+	{"default_page_limit", func(cfg types.DBConfig) string { return nonNegative(cfg.DefaultPageLimit) }},
+	{"max_bulk_records", func(cfg types.DBConfig) string { return nonNegative(cfg.MaxBulkRecords) }},
+	{"consistency", func(cfg types.DBConfig) string {
+		switch cfg.Consistency {
+		case "", "strong", "eventual":
+			return ""
+		}
+		return fmt.Sprintf("%q must be strong or eventual", cfg.Consistency)
+	}},
+	{"write_queue_flush_ms", func(cfg types.DBConfig) string { return nonNegative(cfg.WriteQueueFlushMs) }},
+	{"max_records_per_collection", func(cfg types.DBConfig) string { return nonNegative(cfg.MaxRecordsPerCollection) }},
+	{"max_collection_size_bytes", func(cfg types.DBConfig) string {
+		if cfg.MaxCollectionSizeBytes < 0 {
+			return "must not be negative"
+		}
+		return ""
+	}},
+	{"key_derivation", func(cfg types.DBConfig) string {
+		switch cfg.KeyDerivation {
+		case "", "random":
+			return ""
+		case "argon2":
+			if cfg.MasterPassword == "" && os.Getenv("KITE_MASTER_PASSWORD") == "" {
+				return "argon2 requires master_password or KITE_MASTER_PASSWORD"
+			}
+			return ""
+		}
+		return fmt.Sprintf("%q must be random or argon2", cfg.KeyDerivation)
+	}},
+	{"jwt_secret", func(cfg types.DBConfig) string {
+		if cfg.JWTSecret != "" && len(cfg.JWTSecret) < 32 {
+			return "must be at least 32 characters"
+		}
+		return ""
+	}},
+	{"tls_cert_file", func(cfg types.DBConfig) string { return existingFile(cfg.TLSCertFile) }},
+	{"tls_key_file", func(cfg types.DBConfig) string {
+		if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+			return "tls_cert_file and tls_key_file must be set together"
+		}
+		return existingFile(cfg.TLSKeyFile)
+	}},
+	{"log_level", func(cfg types.DBConfig) string {
+		if _, err := ParseLogLevel(cfg.LogLevel); err != nil {
+			return err.Error()
+		}
+		return ""
+	}},
+	{"gin_mode", func(cfg types.DBConfig) string {
+		switch cfg.GinMode {
+		case "", "debug", "release", "test":
+			return ""
+		}
+		return fmt.Sprintf("%q must be debug, release or test", cfg.GinMode)
+	}},
+	{"users", func(cfg types.DBConfig) string {
+		for _, u := range cfg.Users {
+			switch u.Role {
+			case "admin", "readwrite", "readonly":
+			default:
+				return fmt.Sprintf("user %s: role %q must be admin, readwrite or readonly", u.Username, u.Role)
+			}
+			if u.Role != "admin" && u.SchemaName == "" {
+				return fmt.Sprintf("user %s: schema_name is required unless the role is admin", u.Username)
+			}
+		}
+		return ""
+	}},
+}
+
+func required(value string) string {
+	if value == "" {
+		return "is required"
+	}
+	return ""
+}
+
+func nonNegative(n int) string {
+	if n < 0 {
+		return fmt.Sprintf("%d must not be negative", n)
+	}
+	return ""
+}
+
+func positiveDuration(s string) string {
+	if s == "" {
+		return ""
+	}
+	if d, err := time.ParseDuration(s); err != nil || d <= 0 {
+		return fmt.Sprintf("%q is not a positive duration such as 1h", s)
+	}
+	return ""
+}
+
+func nonNegativePtr(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return nonNegative(*n)
+}
+
+func validPort(port string, isRequired bool) string {
+	if port == "" {
+		if isRequired {
+			return "is required"
+		}
+		return ""
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Sprintf("%q is not a port number between 1 and 65535", port)
+	}
+	return ""
+}
+
+func validPermission(perm string) string {
+	if _, err := ParsePermission(perm, 0); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func existingFile(path string) string {
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Sprintf("%s does not exist", path)
+	}
+	return ""
+}
+
+// Fields lists the config fields Validate checks, in report order.
+func Fields() []string {
+	fields := make([]string, len(checks))
+	for i, c := range checks {
+		fields[i] = c.field
+	}
+	return fields
+}
+
+// Validate checks every field of cfg. A connection string is applied before
+// the connection fields are checked, as it is when the config is loaded.
+func Validate(cfg types.DBConfig) []types.ValidationError {
+	if resolved, err := Resolve(cfg); err == nil {
+		cfg = resolved
+	}
+
+	var errs []types.ValidationError
+	for _, c := range checks {
+		if msg := c.fn(cfg); msg != "" {
+			errs = append(errs, types.ValidationError{Field: c.field, Message: msg})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+const (
+	PermRead   = "read"
+	PermWrite  = "write"
+	PermDelete = "delete"
+	PermDrop   = "drop"
+	// PermLock allows freezing a collection read-only and unfreezing it.
+	PermLock = "lock"
+)
+
+func ValidPermission(p string) bool {
+	return p == PermRead || p == PermWrite || p == PermDelete || p == PermDrop || p == PermLock
+}
+
+// CheckACL reports whether identity holds permission on the collection. A
+// collection without an ACL is open to everyone.
+func CheckACL(collectionName, schemaName, identity, permission string) (bool, error) {
+	meta, err := ReadMeta(collectionName, schemaName)
+	if err != nil {
+		return false, err
+	}
+	if len(meta.ACL) == 0 {
+		return true, nil
+	}
+	for _, entry := range meta.ACL {
+		if entry.Identity != identity {
+			continue
+		}
+		for _, p := range entry.Permissions {
+			if p == permission {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+func schemaACLPath(schemaName string) string {
+	return filepath.Join(schemaDir(schemaName), ".acl.json")
+}
+
+// ReadSchemaACL returns the schema's ACL, or nil if it has none.
+func ReadSchemaACL(schemaName string) (*types.SchemaACL, error) {
+	data, err := os.ReadFile(schemaACLPath(schemaName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema ACL: %v", err)
+	}
+	var acl types.SchemaACL
+	if err := json.Unmarshal(data, &acl); err != nil {
+		return nil, fmt.Errorf("failed to parse schema ACL: %v", err)
+	}
+	return &acl, nil
+}
+
+// SetSchemaACL replaces the permissions identity holds on the schema.
+func SetSchemaACL(schemaName, identity string, permissions []string) error {
+	for _, p := range permissions {
+		if !ValidPermission(p) {
+			return kerrors.New(kerrors.ErrInvalidRequest, "unknown permission %q (expected read, write, delete, drop or lock)", p)
+		}
+	}
+	if _, err := os.Stat(schemaDir(schemaName)); err != nil {
+		return kerrors.New(kerrors.ErrInvalidRequest, "schema %s does not exist", schemaName)
+	}
+
+	acl, err := ReadSchemaACL(schemaName)
+	if err != nil {
+		return err
+	}
+	if acl == nil {
+		acl = &types.SchemaACL{}
+	}
+	if acl.Identities == nil {
+		acl.Identities = make(map[string][]string)
+	}
+	acl.Identities[identity] = permissions
+
+	data, err := json.MarshalIndent(acl, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema ACL: %v", err)
+	}
+	if err := writeFile(schemaACLPath(schemaName), data); err != nil {
+		return fmt.Errorf("failed to write schema ACL: %v", err)
+	}
+	return nil
+}
+
+// CheckSchemaACL reports whether identity holds permission on the schema. A
+// schema without an ACL is open to everyone.
+func CheckSchemaACL(schemaName, identity, permission string) (bool, error) {
+	acl, err := ReadSchemaACL(schemaName)
+	if err != nil || acl == nil {
+		return acl == nil, err
+	}
+	for _, p := range acl.Identities[identity] {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CheckAccess applies the schema ACL and then the collection ACL. An identity
+// missing from the schema ACL is refused without consulting the collection;
+// otherwise a collection ACL, when there is one, takes precedence over the
+// schema grant. denied names the level that refused the request.
+func CheckAccess(collectionName, schemaName, identity, permission string) (allowed bool, denied string, err error) {
+	acl, err := ReadSchemaACL(schemaName)
+	if err != nil {
+		return false, "", err
+	}
+	if acl != nil {
+		if _, listed := acl.Identities[identity]; !listed {
+			return false, "schema", nil
+		}
+		meta, err := ReadMeta(collectionName, schemaName)
+		if err != nil {
+			return false, "", err
+		}
+		if len(meta.ACL) == 0 {
+			allowed, err := CheckSchemaACL(schemaName, identity, permission)
+			if err != nil || !allowed {
+				return false, "schema", err
+			}
+			return true, "", nil
+		}
+	}
+
+	allowed, err = CheckACL(collectionName, schemaName, identity, permission)
+	if err != nil || !allowed {
+		return false, "collection", err
+	}
+	return true, "", nil
+}
+
+// SchemaACLIdentities returns the identities named in the schema ACL, sorted.
+func SchemaACLIdentities(acl *types.SchemaACL) []string {
+	identities := make([]string, 0, len(acl.Identities))
+	for identity := range acl.Identities {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+	return identities
+}
+
+func GetACL(collectionName, schemaName string) ([]types.ACLEntry, error) {
+	meta, err := ReadMeta(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	return meta.ACL, nil
+}
+
+// SetACL replaces the permissions granted to identity.
+func SetACL(collectionName, schemaName, identity string, permissions []string) error {
+	for _, p := range permissions {
+		if !ValidPermission(p) {
+			return kerrors.New(kerrors.ErrInvalidRequest, "unknown permission %q (expected read, write, delete, drop or lock)", p)
+		}
+	}
+
+	return UpdateMeta(collectionName, schemaName, func(meta *types.CollectionMeta) {
+		for i, entry := range meta.ACL {
+			if entry.Identity == identity {
+				meta.ACL[i].Permissions = permissions
+				return
+			}
+		}
+		meta.ACL = append(meta.ACL, types.ACLEntry{Identity: identity, Permissions: permissions})
+	})
+}
+
+func RevokeACL(collectionName, schemaName, identity string) error {
+	found := false
+	err := UpdateMeta(collectionName, schemaName, func(meta *types.CollectionMeta) {
+		for i, entry := range meta.ACL {
+			if entry.Identity == identity {
+				meta.ACL = append(meta.ACL[:i], meta.ACL[i+1:]...)
+				found = true
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return kerrors.New(kerrors.ErrInvalidRequest, "identity %s has no ACL entry on %s", identity, collectionName)
+	}
+	return nil
+}
@@ -6,78 +6,114 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
-	"kite/src/helper"
+	kerrors "kite/src/errors"
+	"kite/src/history"
 	"kite/src/types"
-
-	"github.com/google/uuid"
 )
 
-func AddCollection(collectionName, schemaName, jsonData string) error {
-	dir := filepath.Join("..", "db")
-	if schemaName != "" {
-		dir = filepath.Join("..", "db", schemaName)
-	}
+func addCollection(collectionName, schemaName, jsonData string) error {
+	return AddCollectionAt(collectionName, schemaName, "", jsonData)
+}
 
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("failed to create directory %s: %v", dir, err)
+// AddCollectionAt is Store.AddCollection with the data and key files stored in
+// path, an absolute directory, rather than the schema directory. The path is
+// kept in the collection's schema file.
+func AddCollectionAt(collectionName, schemaName, path, jsonData string) error {
+	if err := EnsureSchema(schemaName, ""); err != nil {
+		return err
 	}
-	if err := os.Chmod(dir, 0700); err != nil {
-		return fmt.Errorf("failed to set permissions on %s: %v", dir, err)
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
+	if collectionExists(collectionName, schemaName) {
+		return kerrors.New(kerrors.ErrCollectionExists, "collection %s already exists in %s", collectionName, dataDir(collectionName, schemaName))
+	}
+	// The path goes into the schema file only once the collection exists,
+	// so a failed create leaves nothing pointing at it.
+	dir := dataDir(collectionName, schemaName)
+	if path != "" {
+		if err := ValidateCollectionPath(path); err != nil {
+			return err
+		}
+		if err := mkdirAll(path); err != nil {
+			return err
+		}
+		dir = filepath.Clean(path)
+	}
 	collectionPath := filepath.Join(dir, collectionName+".txt")
 	if _, err := os.Stat(collectionPath); err == nil {
-		return fmt.Errorf("collection %s already exists in %s", collectionName, dir)
+		return kerrors.New(kerrors.ErrCollectionExists, "collection %s already exists in %s", collectionName, dir)
 	}
 
-	key, err := helper.GenerateKey()
+	key, derived, err := newCollectionKey(collectionName, schemaName)
 	if err != nil {
-		return fmt.Errorf("failed to generate key: %v", err)
+		return err
 	}
 
-	var dataToEncrypt []byte
-	if jsonData == "" {
-		dataToEncrypt = []byte("[]")
-	} else {
-		cleanedJSON := strings.Trim(jsonData, "'\"")
-		var inputData map[string]interface{}
-		if err := json.Unmarshal([]byte(cleanedJSON), &inputData); err != nil {
-			return fmt.Errorf("failed to parse JSON data: %v", err)
-		}
-
-		now := time.Now().UTC().Format(time.RFC3339)
-		record := types.Record{
-			"_id":       uuid.New().String(),
-			"createdAt": now,
-			"updatedAt": now,
-			"_version":  float64(0),
-		}
-		for k, v := range inputData {
-			if k != "_id" && k != "createdAt" && k != "updatedAt" && k != "_version" {
-				record[k] = v
+	// jsonData is empty, a single initial record, or an array of them.
+	records := []types.Record{}
+	if cleanedJSON := strings.TrimSpace(strings.Trim(jsonData, "'\"")); cleanedJSON != "" {
+		var inputs []map[string]interface{}
+		isArray := cleanedJSON[0] == '['
+		if isArray {
+			if err := unmarshalJSON([]byte(cleanedJSON), &inputs); err != nil {
+				return kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
+			}
+		} else {
+			var inputData map[string]interface{}
+			if err := unmarshalJSON([]byte(cleanedJSON), &inputData); err != nil {
+				return kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
 			}
+			inputs = append(inputs, inputData)
 		}
-
-		dataArray := []types.Record{record}
-		dataToEncrypt, err = json.Marshal(dataArray)
-		if err != nil {
-			return fmt.Errorf("failed to marshal JSON data: %v", err)
+		for i, inputData := range inputs {
+			if isArray && inputData == nil {
+				return kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: element %d is not a JSON object", i)
+			}
+			records = append(records, newRecord(inputData))
 		}
 	}
+	recordCount := len(records)
+	dataToEncrypt, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON data: %v", err)
+	}
 
-	encrypted, err := helper.Encrypt(dataToEncrypt, key)
+	encrypted, err := encryptCollectionData(collectionName, schemaName, dataToEncrypt, key)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt data: %v", err)
+		return err
 	}
 
-	if err := os.WriteFile(collectionPath, []byte(encrypted), 0600); err != nil {
+	if err := writeCollectionAtomic(collectionPath, encrypted); err != nil {
 		return fmt.Errorf("failed to write collection file: %v", err)
 	}
 
 	keyPath := filepath.Join(dir, collectionName+".key")
-	if err := os.WriteFile(keyPath, key, 0600); err != nil {
-		return fmt.Errorf("failed to write key file: %v", err)
+	if !derived {
+		if err := writeCollectionAtomic(keyPath, key); err != nil {
+			os.Remove(collectionPath)
+			return fmt.Errorf("failed to write key file: %v", err)
+		}
+	}
+	if path != "" {
+		schema, err := ReadCollectionSchema(collectionName, schemaName)
+		if err == nil {
+			schema.Path = dir
+			err = writeCollectionSchema(collectionName, schemaName, schema)
+		}
+		if err != nil {
+			os.Remove(collectionPath)
+			os.Remove(keyPath)
+			return err
+		}
+	}
+	markCreated(collectionName, schemaName)
+
+	if err := history.SaveSnapshot(schemaDir(schemaName), collectionName, "create", recordCount, encrypted, historyLimit()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
 
 	fmt.Printf("Created collection %s at %s\n", collectionName, collectionPath)
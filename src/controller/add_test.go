@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestAddCollectionAtCustomPath(t *testing.T) {
+	s := newTestStore(t)
+	custom := filepath.Join(t.TempDir(), "data")
+
+	if err := s.AddCollectionAt("notes", "public", custom, `[{"title":"a"}]`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(custom, "notes.txt")); err != nil {
+		t.Fatalf("data file not in the custom path: %v", err)
+	}
+	records, err := ReadCollection("notes", "public")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("ReadCollection = %v, %v", records, err)
+	}
+
+	if err := s.AddCollectionAt("relative", "public", "data", ""); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+		t.Errorf("relative path: err = %v, want %s", err, kerrors.ErrInvalidRequest)
+	}
+}
+
+func TestFailedAddCollectionAtLeavesNoSchemaFile(t *testing.T) {
+	s := newTestStore(t)
+	custom := filepath.Join(t.TempDir(), "data")
+
+	if err := s.AddCollectionAt("notes", "public", custom, `{not json`); err == nil {
+		t.Fatal("expected a JSON error")
+	}
+	if _, err := os.Stat(collectionSchemaPath("notes", "public")); !os.IsNotExist(err) {
+		t.Fatalf("schema file left behind: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(custom, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatalf("data file left behind: %v", err)
+	}
+
+	// The next attempt, without a custom path, is not redirected.
+	if err := s.AddCollection("notes", "public", `[]`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(schemaDir("public"), "notes.txt")); err != nil {
+		t.Fatalf("data file not in the schema directory: %v", err)
+	}
+}
+
+func TestAddCollectionInitialData(t *testing.T) {
+	tests := []struct {
+		name, data string
+		records    int
+	}{
+		{"empty", "", 0},
+		{"empty array", "[]", 0},
+		{"array", ` [{"name":"ann"},{"name":"bob"}]`, 2},
+		{"object", `{"name":"ann"}`, 1},
+	}
+	s := newTestStore(t)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := fmt.Sprintf("people%d", i)
+			if err := s.AddCollection(name, "public", tt.data); err != nil {
+				t.Fatal(err)
+			}
+			records, err := ReadCollection(name, "public")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tt.records {
+				t.Fatalf("created %d records, want %d", len(records), tt.records)
+			}
+			for _, record := range records {
+				if record["_id"] == nil || record["createdAt"] == nil || record["name"] == nil {
+					t.Errorf("record %v is missing its metadata or fields", record)
+				}
+			}
+		})
+	}
+
+	if err := s.AddCollection("broken", "public", `[{"name":"ann"},"bob"]`); err == nil {
+		t.Error("an array with a non-object element was accepted")
+	}
+}
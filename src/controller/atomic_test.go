@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failWritesHalfway makes writeCollectionAtomic write half of its data and
+// then call stop, until the test ends.
+func failWritesHalfway(t *testing.T, stop func() error) {
+	t.Helper()
+	orig := writeTemp
+	writeTemp = func(f *os.File, data []byte) (int, error) {
+		n, _ := f.Write(data[:len(data)/2])
+		return n, stop()
+	}
+	t.Cleanup(func() { writeTemp = orig })
+}
+
+func TestWriteFailureKeepsCollection(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"},{"title":"b"}]`)
+	path := filepath.Join(schemaDir("public"), "notes.txt")
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := records[0]["_id"].(string)
+
+	failWritesHalfway(t, func() error { return errors.New("disk full") })
+	writes := map[string]func() error{
+		"insert": func() error { return s.InsertRecord(context.Background(), "notes", `{"title":"c"}`, "public") },
+		"edit": func() error {
+			_, err := s.EditCollection(context.Background(), "notes", id, `{"title":"z"}`, "public", "")
+			return err
+		},
+		"delete": func() error { return s.MoveRecord(context.Background(), "notes", id, "public", "") },
+	}
+	for name, write := range writes {
+		if err := write(); err == nil {
+			t.Errorf("%s succeeded with a failing write", name)
+		}
+		after, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(after) != string(before) {
+			t.Errorf("%s changed the collection file after a failed write", name)
+		}
+	}
+
+	entries, err := os.ReadDir(schemaDir("public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("failed write left %s behind", entry.Name())
+		}
+	}
+}
+
+// A crash mid-write leaves the temporary file behind but never touches the
+// collection file.
+func TestCrashMidWriteKeepsCollection(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+
+	failWritesHalfway(t, func() error { panic("crash") })
+	func() {
+		defer func() { recover() }()
+		s.InsertRecord(context.Background(), "notes", `{"title":"b"}`, "public")
+		t.Error("insert returned after the crash")
+	}()
+
+	records, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatalf("collection unreadable after a crash mid-write: %v", err)
+	}
+	if len(records) != 1 || records[0]["title"] != "a" {
+		t.Errorf("records after a crash mid-write = %v, want the original one", records)
+	}
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	kdiff "kite/src/diff"
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+// BatchPatch merges patch into every record matching filter, leaving fields
+// the patch does not mention alone, and returns the field changes of each
+// record updated. If patch carries _expected_version, every matched record
+// must be at that version or nothing is written.
+func BatchPatch(collectionName, schemaName string, filter map[string]string, patch map[string]interface{}) ([]types.RecordDiff, error) {
+	return batchUpdate(collectionName, schemaName, filter, patch, false)
+}
+
+// BatchEdit is BatchPatch with replace semantics: the user fields of every
+// matching record are replaced by patch.
+func BatchEdit(collectionName, schemaName string, filter map[string]string, patch map[string]interface{}) ([]types.RecordDiff, error) {
+	return batchUpdate(collectionName, schemaName, filter, patch, true)
+}
+
+func batchUpdate(collectionName, schemaName string, filter map[string]string, patch map[string]interface{}, replace bool) ([]types.RecordDiff, error) {
+	if eventSourced, err := isEventSourced(collectionName, schemaName); err != nil {
+		return nil, err
+	} else if eventSourced {
+		return nil, kerrors.New(kerrors.ErrEventSourced, "collection %s is event sourced; records cannot be edited", collectionName)
+	}
+
+	rawVersion, checkVersion := patch["_expected_version"]
+	expectedVersion, err := helper.ToFloat64(rawVersion)
+	if checkVersion && err != nil {
+		return nil, kerrors.New(kerrors.ErrInvalidRequest, "_expected_version must be a number")
+	}
+
+	var exprs []types.FilterExpression
+	for field, value := range filter {
+		exprs = append(exprs, types.FilterExpression{Field: field, Op: "eq", Value: value})
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	records, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []int
+	for i, record := range records {
+		if !matchesFilter(record, exprs) {
+			continue
+		}
+		if err := checkLock(record, ""); err != nil {
+			return nil, err
+		}
+		if version, _ := helper.ToFloat64(record["_version"]); checkVersion && version != expectedVersion {
+			return nil, kerrors.New(kerrors.ErrVersionConflict, "record %s is at version %v, expected %v", record["_id"], version, expectedVersion)
+		}
+		matched = append(matched, i)
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	diffs := make([]types.RecordDiff, 0, len(matched))
+	for _, i := range matched {
+		record := records[i]
+		before := userFields(record)
+		if replace {
+			for k := range before {
+				delete(record, k)
+			}
+		}
+		for k, v := range patch {
+			if !isReservedField(k) && k != "_expected_version" {
+				record[k] = v
+			}
+		}
+		version, _ := helper.ToFloat64(record["_version"])
+		record["_version"] = version + 1
+		record["updatedAt"] = now
+		id, _ := record["_id"].(string)
+		diffs = append(diffs, types.RecordDiff{ID: id, Changes: kdiff.RecordDiff(before, userFields(record))})
+	}
+
+	if err := writeRecords(collectionName, schemaName, "update", records, key); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+// BulkUpsert inserts each record, or replaces the user fields of the
+// existing record whose keyField has the same value. The whole batch is one
+// read-modify-write cycle under a single lock; records that fail are
+// reported and skipped.
+func BulkUpsert(collectionName, schemaName, keyField string, records []map[string]interface{}) (inserted, updated int, errs []types.BulkError, err error) {
+	if keyField == "" || isReservedField(keyField) {
+		return 0, 0, nil, kerrors.New(kerrors.ErrInvalidRequest, "key_field must name a user field")
+	}
+	if eventSourced, err := isEventSourced(collectionName, schemaName); err != nil {
+		return 0, 0, nil, err
+	} else if eventSourced {
+		return 0, 0, nil, kerrors.New(kerrors.ErrEventSourced, "collection %s is event sourced; records cannot be edited", collectionName)
+	}
+	if !collectionExists(collectionName, schemaName) {
+		if err := addCollection(collectionName, schemaName, ""); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer unlock()
+
+	existing, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	rules, err := insertRulesFor(collectionName, schemaName)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	index := make(map[string]int, len(existing))
+	buildIndex := func() {
+		clear(index)
+		for i, record := range existing {
+			if v, ok := record[keyField]; ok {
+				if k, err := dedupKey(v); err == nil {
+					index[k] = i
+				}
+			}
+		}
+	}
+	buildIndex()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for n, inputData := range records {
+		v, ok := inputData[keyField]
+		if !ok {
+			errs = append(errs, types.BulkError{Index: n, Record: inputData, Error: fmt.Sprintf("missing key field %s", keyField)})
+			continue
+		}
+		k, err := dedupKey(v)
+		if err != nil {
+			errs = append(errs, types.BulkError{Index: n, Record: inputData, Error: err.Error()})
+			continue
+		}
+
+		if i, found := index[k]; found {
+			current := existing[i]
+			if err := checkLock(current, ""); err != nil {
+				errs = append(errs, types.BulkError{Index: n, Record: inputData, Error: err.Error()})
+				continue
+			}
+			replacement := newRecord(inputData)
+			for _, field := range []string{"_id", "createdAt", "_locked_by", "_lock_expires_at"} {
+				if v, ok := current[field]; ok {
+					replacement[field] = v
+				} else {
+					delete(replacement, field)
+				}
+			}
+			version, _ := helper.ToFloat64(current["_version"])
+			replacement["_version"] = version + 1
+			replacement["updatedAt"] = now
+			existing[i] = replacement
+			updated++
+			continue
+		}
+
+		before := len(existing)
+		if existing, err = rules.appendRecord(existing, inputData); err != nil {
+			errs = append(errs, types.BulkError{Index: n, Record: inputData, Error: err.Error()})
+			continue
+		}
+		if len(existing) == before+1 {
+			index[k] = before
+		} else {
+			// Eviction moved records around.
+			buildIndex()
+		}
+		inserted++
+	}
+
+	if inserted+updated > 0 {
+		if err := writeRecords(collectionName, schemaName, "upsert", existing, key); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	return inserted, updated, errs, nil
+}
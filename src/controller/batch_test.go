@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestBatchPatchUpdatesOnlyMatches(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "tasks", `[
+		{"title":"a","status":"active","owner":"ann"},
+		{"title":"b","status":"done","owner":"ann"},
+		{"title":"c","status":"active","owner":"bob"},
+		{"title":"d","status":"done","owner":"bob"},
+		{"title":"e","status":"active","owner":"cy"}
+	]`)
+
+	diffs, err := s.BatchPatch("tasks", "public", map[string]string{"status": "active"}, map[string]interface{}{"status": "inactive"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("updated %d records, want 3", len(diffs))
+	}
+
+	records, err := ReadCollection("tasks", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		switch record["title"] {
+		case "a", "c", "e":
+			if record["status"] != "inactive" || record["_version"] != 1.0 || record["owner"] == nil {
+				t.Errorf("matched record %v was not patched", record)
+			}
+		default:
+			if record["status"] != "done" || record["_version"] != 0.0 {
+				t.Errorf("unmatched record %v was changed", record)
+			}
+		}
+	}
+
+	// One matched record is already at version 1, so nothing is written.
+	_, err = s.BatchPatch("tasks", "public", map[string]string{"owner": "bob"}, map[string]interface{}{"status": "archived", "_expected_version": 0})
+	if kerrors.Code(err) != kerrors.ErrVersionConflict {
+		t.Fatalf("stale batch = %v, want %s", err, kerrors.ErrVersionConflict)
+	}
+	records, err = ReadCollection("tasks", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if record["status"] == "archived" {
+			t.Errorf("record %v was written by a failed batch", record)
+		}
+	}
+}
+
+func TestBulkUpsert(t *testing.T) {
+	s := newTestStore(t)
+	var existing []string
+	for i := 0; i < 5; i++ {
+		existing = append(existing, fmt.Sprintf(`{"email":"u%d@example.com","plan":"free","note":"old"}`, i))
+	}
+	addTestCollection(t, s, "users", "["+strings.Join(existing, ",")+"]")
+	before, err := ReadCollection("users", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var batch []map[string]interface{}
+	for i := 0; i < 10; i++ {
+		batch = append(batch, map[string]interface{}{"email": fmt.Sprintf("u%d@example.com", i), "plan": "pro"})
+	}
+	batch = append(batch, map[string]interface{}{"plan": "pro"})
+
+	inserted, updated, errs, err := s.BulkUpsert("users", "public", "email", batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted != 5 || updated != 5 {
+		t.Errorf("inserted %d and updated %d, want 5 and 5", inserted, updated)
+	}
+	if len(errs) != 1 || errs[0].Index != 10 {
+		t.Errorf("errors = %+v, want the record without an email at index 10", errs)
+	}
+
+	records, err := ReadCollection("users", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 10 {
+		t.Fatalf("collection holds %d records, want 10", len(records))
+	}
+	for i, record := range records {
+		if record["email"] != fmt.Sprintf("u%d@example.com", i) || record["plan"] != "pro" {
+			t.Errorf("record %d = %v, want plan pro", i, record)
+		}
+		if _, ok := record["note"]; ok {
+			t.Errorf("record %d kept note after a full replace: %v", i, record)
+		}
+		if i < 5 && (record["_id"] != before[i]["_id"] || record["createdAt"] != before[i]["createdAt"] || record["_version"] != 1.0) {
+			t.Errorf("updated record %d = %v, want its _id and createdAt kept at version 1", i, record)
+		}
+	}
+
+	if _, _, _, err := s.BulkUpsert("users", "public", "_id", batch); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+		t.Errorf("upsert keyed on _id = %v, want %s", err, kerrors.ErrInvalidRequest)
+	}
+}
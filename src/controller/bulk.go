@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+// parseBulkRecord accepts either a JSON object or a string holding one.
+func parseBulkRecord(raw json.RawMessage) (map[string]interface{}, error) {
+	data := []byte(raw)
+	var quoted string
+	if err := json.Unmarshal(raw, &quoted); err == nil {
+		data = []byte(strings.Trim(quoted, "'\""))
+	}
+
+	var inputData map[string]interface{}
+	if err := json.Unmarshal(data, &inputData); err != nil {
+		return nil, kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
+	}
+	if inputData == nil {
+		return nil, kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: record must be a JSON object")
+	}
+	return inputData, nil
+}
+
+// DefaultMaxBulkRecords caps a bulk insert when max_bulk_records is not set.
+const DefaultMaxBulkRecords = 1000
+
+func maxBulkRecords() int {
+	if n := currentConfig().MaxBulkRecords; n > 0 {
+		return n
+	}
+	return DefaultMaxBulkRecords
+}
+
+// InsertMany appends records in a single write and returns their new _ids.
+// One invalid record aborts the whole batch.
+func InsertMany(collectionName string, records []map[string]interface{}, schemaName string) ([]string, error) {
+	rawRecords := make([]json.RawMessage, len(records))
+	for i, record := range records {
+		raw, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal record %d: %v", i, err)
+		}
+		rawRecords[i] = raw
+	}
+	ids, _, err := BulkInsertRecords(collectionName, schemaName, rawRecords, false, nil)
+	return ids, err
+}
+
+// BulkInsertRecords appends all records in a single write. Unless partial is
+// set, one bad record aborts the whole batch and nothing is written. With
+// merge set, a record whose _id already exists is merged into it instead of
+// being appended. A missing collection is created for the batch, and dropped
+// again if nothing is inserted.
+func BulkInsertRecords(collectionName, schemaName string, rawRecords []json.RawMessage, partial bool, merge *types.MergeOptions) (successful []string, failures []types.BulkError, err error) {
+	if max := maxBulkRecords(); len(rawRecords) > max {
+		return nil, nil, kerrors.New(kerrors.ErrInvalidRequest, "bulk insert of %d records exceeds the limit of %d", len(rawRecords), max)
+	}
+	created := false
+	if !collectionExists(collectionName, schemaName) {
+		if err := addCollection(collectionName, schemaName, ""); err != nil {
+			return nil, nil, err
+		}
+		created = true
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, nil, err
+	}
+	dropped := false
+	defer func() {
+		unlock()
+		if dropped {
+			removeLockFiles(collectionName, schemaName)
+		}
+	}()
+
+	records, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Another writer may have filled the new collection before the lock
+	// was taken, in which case it is kept.
+	created = created && len(records) == 0
+
+	eventSourced, err := isEventSourced(collectionName, schemaName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, raw := range rawRecords {
+		inputData, err := parseBulkRecord(raw)
+		if err != nil {
+			failures = append(failures, types.BulkError{Index: i, Error: err.Error()})
+			continue
+		}
+		if id, ok := inputData["_id"].(string); ok && merge != nil && !eventSourced {
+			if j := recordIndex(records, id); j >= 0 {
+				if err := checkLock(records[j], ""); err != nil {
+					failures = append(failures, types.BulkError{Index: i, Record: inputData, Error: err.Error()})
+					continue
+				}
+				merged, err := MergeRecords(records[j], inputData, *merge)
+				if err != nil {
+					failures = append(failures, types.BulkError{Index: i, Record: inputData, Error: err.Error()})
+					continue
+				}
+				records[j] = merged
+				successful = append(successful, id)
+				continue
+			}
+		}
+		record := newRecord(inputData)
+		if eventSourced {
+			if record, err = newEvent(records, inputData); err != nil {
+				failures = append(failures, types.BulkError{Index: i, Record: inputData, Error: err.Error()})
+				continue
+			}
+		}
+		records = append(records, record)
+		successful = append(successful, record["_id"].(string))
+	}
+
+	if created && (len(successful) == 0 || len(failures) > 0 && !partial) {
+		if err := dropCollectionLocked(collectionName, schemaName); err != nil {
+			return nil, nil, err
+		}
+		dropped = true
+	}
+	if len(failures) > 0 && !partial {
+		return nil, failures, kerrors.New(kerrors.ErrBulkInsertFailed, "bulk insert aborted: %d of %d records are invalid", len(failures), len(rawRecords))
+	}
+
+	if len(successful) > 0 {
+		if err := writeRecords(collectionName, schemaName, "insert", records, key); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fmt.Printf("Inserted %d records into collection %s\n", len(successful), collectionName)
+	return successful, failures, nil
+}
+
+func recordIndex(records []types.Record, id string) int {
+	for i, record := range records {
+		if record["_id"] == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestAbortedBulkInsertLeavesNoCollection(t *testing.T) {
+	s := newTestStore(t)
+	records := []json.RawMessage{json.RawMessage(`{"title":"a"}`), json.RawMessage(`"not an object"`)}
+
+	if _, _, err := BulkInsertRecords("notes", "public", records, false, nil); err == nil {
+		t.Fatal("bulk insert with an invalid record succeeded")
+	}
+	if collectionExists("notes", "public") {
+		t.Error("aborted bulk insert created the collection")
+	}
+	entries, err := os.ReadDir(schemaDir("public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			t.Errorf("aborted bulk insert left %s behind", entry.Name())
+		}
+	}
+
+	// An existing collection is kept, even when empty.
+	addTestCollection(t, s, "todo", "")
+	if _, _, err := BulkInsertRecords("todo", "public", records, false, nil); err == nil {
+		t.Fatal("bulk insert with an invalid record succeeded")
+	}
+	if !collectionExists("todo", "public") {
+		t.Error("aborted bulk insert dropped an existing collection")
+	}
+
+	if ids, _, err := BulkInsertRecords("partial", "public", records, true, nil); err != nil || len(ids) != 1 {
+		t.Fatalf("partial bulk insert = %v, %v", ids, err)
+	}
+	if !collectionExists("partial", "public") {
+		t.Error("partial bulk insert did not create the collection")
+	}
+}
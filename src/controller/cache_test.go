@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReadsDecryptOnce(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+
+	decrypts := 0
+	orig := decrypt
+	decrypt = func(data string, key []byte) ([]byte, error) {
+		decrypts++
+		return orig(data, key)
+	}
+	t.Cleanup(func() { decrypt = orig })
+
+	for i := 0; i < 100; i++ {
+		if _, err := ReadCollection("notes", "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if decrypts > 1 {
+		t.Errorf("100 reads decrypted the collection %d times, want at most once", decrypts)
+	}
+
+	// A write changes the file, so the next read decrypts it again.
+	if err := s.InsertRecord(context.Background(), "notes", `{"title":"b"}`, "public"); err != nil {
+		t.Fatal(err)
+	}
+	decrypts = 0
+	records, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("read %d records after the insert, want 2", len(records))
+	}
+	if decrypts != 1 {
+		t.Errorf("read after a write decrypted %d times, want 1", decrypts)
+	}
+}
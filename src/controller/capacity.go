@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+
+	kerrors "kite/src/errors"
+)
+
+// checkCollectionSize rejects writes to a collection file that has already
+// reached max_collection_size_bytes, without decrypting it.
+func checkCollectionSize(collectionName, collectionPath string) error {
+	limit := currentConfig().MaxCollectionSizeBytes
+	if limit <= 0 {
+		return nil
+	}
+	info, err := os.Stat(collectionPath)
+	if err != nil {
+		return collectionReadError(err)
+	}
+	if info.Size() >= limit {
+		return kerrors.New(kerrors.ErrCapacityExceeded, "collection %s is at maximum size (%d bytes)", collectionName, limit)
+	}
+	return nil
+}
+
+// recordLimit returns the record limit for a collection, preferring its own
+// max_records over the global setting, and its eviction policy. A zero limit
+// means unlimited.
+func recordLimit(collectionName, schemaName string) (int, string, error) {
+	schema, err := ReadCollectionSchema(collectionName, schemaName)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read record limit: %v", err)
+	}
+	policy := schema.EvictionPolicy
+	if policy == "" {
+		policy = EvictionReject
+	}
+	if policy != EvictionReject && policy != EvictionLRU {
+		return 0, "", fmt.Errorf("unknown eviction policy %q", policy)
+	}
+	if schema.MaxRecords > 0 {
+		return schema.MaxRecords, policy, nil
+	}
+	return currentConfig().MaxRecordsPerCollection, policy, nil
+}
+
+func capacityError(collectionName string, limit int) error {
+	return kerrors.New(kerrors.ErrCapacityExceeded, "collection %s is at maximum capacity (%d records)", collectionName, limit)
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+func TestRecordLimit(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64, MaxRecordsPerCollection: 5}
+	Configure(cfg)
+	s := NewStore(cfg)
+	if err := EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	addTestCollection(t, s, "notes", `[{"n":1}]`)
+	for n := 2; n <= 5; n++ {
+		if err := s.InsertRecord(ctx, "notes", fmt.Sprintf(`{"n":%d}`, n), "public"); err != nil {
+			t.Fatalf("insert %d: %v", n, err)
+		}
+	}
+
+	err := s.InsertRecord(ctx, "notes", `{"n":6}`, "public")
+	if kerrors.Code(err) != kerrors.ErrCapacityExceeded {
+		t.Fatalf("sixth insert = %v, want %s", err, kerrors.ErrCapacityExceeded)
+	}
+	if records, err := ReadCollection("notes", "public"); err != nil || len(records) != 5 {
+		t.Errorf("collection holds %d records (%v), want 5", len(records), err)
+	}
+
+	// The collection's own max_records wins over the global limit.
+	addTestCollection(t, s, "small", `[{"n":1}]`)
+	if err := WriteCollectionSchema("small", "public", types.CollectionSchema{MaxRecords: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertRecord(ctx, "small", `{"n":2}`, "public"); kerrors.Code(err) != kerrors.ErrCapacityExceeded {
+		t.Errorf("insert over max_records = %v, want %s", err, kerrors.ErrCapacityExceeded)
+	}
+}
@@ -0,0 +1,602 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/filelock"
+	"kite/src/helper"
+	"kite/src/history"
+	kid "kite/src/id"
+	"kite/src/types"
+	"kite/src/undo"
+)
+
+// sidecarSuffixes are the optional files stored next to a collection.
+var sidecarSuffixes = []string{".meta.json", ".meta.lock", ".schema.json", ".fork-base", ".lock"}
+
+// lockSuffixes are the sidecars used only for locking.
+var lockSuffixes = []string{".meta.lock", ".lock"}
+
+func schemaDir(schemaName string) string {
+	return filepath.Join(DBPath(), schemaName)
+}
+
+// EnsureSchema creates the schema directory under dbRoot, or under the
+// database root when dbRoot is empty, with the configured directory mode.
+func EnsureSchema(schemaName, dbRoot string) error {
+	dir := schemaDir(schemaName)
+	if dbRoot != "" {
+		dir = filepath.Join(dbRoot, schemaName)
+	}
+	return mkdirAll(dir)
+}
+
+// ValidateSchemaName rejects names that are not a single plain directory
+// name, or that are reserved.
+func ValidateSchemaName(schemaName string) error {
+	switch {
+	case schemaName == "", schemaName == ".", schemaName == "..":
+		return kerrors.New(kerrors.ErrInvalidRequest, "invalid schema name %q", schemaName)
+	case strings.ContainsAny(schemaName, `/\`), strings.HasPrefix(schemaName, "."):
+		return kerrors.New(kerrors.ErrInvalidRequest, "invalid schema name %q", schemaName)
+	case schemaName == SystemSchema:
+		return kerrors.New(kerrors.ErrInvalidRequest, "schema %s is reserved", schemaName)
+	}
+	return nil
+}
+
+// ValidateCollectionName rejects names that are not a plain file name.
+func ValidateCollectionName(collectionName string) error {
+	if collectionName == "" || strings.ContainsAny(collectionName, `/\`) || strings.HasPrefix(collectionName, ".") {
+		return kerrors.New(kerrors.ErrInvalidRequest, "invalid collection name %q", collectionName)
+	}
+	return nil
+}
+
+// ValidateNames checks that a schema and collection name taken from a request
+// are safe to use as path components.
+func ValidateNames(schemaName, collectionName string) error {
+	if err := ValidateSchemaName(schemaName); err != nil {
+		return err
+	}
+	return ValidateCollectionName(collectionName)
+}
+
+// CreateSchema creates an empty schema.
+func CreateSchema(schemaName string) error {
+	if err := ValidateSchemaName(schemaName); err != nil {
+		return err
+	}
+	dir := schemaDir(schemaName)
+	if _, err := os.Stat(dir); err == nil {
+		return kerrors.New(kerrors.ErrSchemaExists, "schema %s already exists", schemaName)
+	}
+	if err := mkdirAll(dir); err != nil {
+		return err
+	}
+	fmt.Printf("Created schema %s\n", schemaName)
+	return nil
+}
+
+// dataDir returns the directory holding a collection's data and key files:
+// the path set in its schema file, or else the schema directory. Sidecar
+// files always stay in the schema directory.
+func dataDir(collectionName, schemaName string) string {
+	if schema, err := ReadCollectionSchema(collectionName, schemaName); err == nil && schema.Path != "" {
+		return schema.Path
+	}
+	return schemaDir(schemaName)
+}
+
+// ValidateCollectionPath checks a custom collection path: it must be
+// absolute and may not contain ".." components.
+func ValidateCollectionPath(path string) error {
+	if !filepath.IsAbs(path) {
+		return kerrors.New(kerrors.ErrInvalidRequest, "collection path %q must be absolute", path)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return kerrors.New(kerrors.ErrInvalidRequest, "collection path %q must not contain ..", path)
+		}
+	}
+	return nil
+}
+
+var (
+	writeLocksMu sync.Mutex
+	// writeLocks holds a one-slot channel per collection and per meta file,
+	// used as a mutex that can be waited on with a timeout.
+	writeLocks = map[string]chan struct{}{}
+)
+
+// DefaultLockTimeout is how long a write waits for a collection lock when
+// lock_timeout is not set.
+const DefaultLockTimeout = 5 * time.Second
+
+func lockTimeout() time.Duration {
+	if d, err := time.ParseDuration(currentConfig().LockTimeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultLockTimeout
+}
+
+// lockCollection serialises read-modify-write cycles on one collection,
+// within this process through a per-collection semaphore and across
+// processes through an advisory lock on <collection>.lock. It gives up after
+// the configured lock timeout. Call the returned function to release both.
+func lockCollection(collectionName, schemaName string) (func(), error) {
+	return acquireLock(undo.Key(schemaName, collectionName), lockPath(schemaDir(schemaName), collectionName), "a write lock on collection "+collectionName)
+}
+
+// lockMeta is lockCollection for the collection's .meta.json, through
+// <collection>.meta.lock. It is separate so that writers already holding the
+// collection lock can still update metadata.
+func lockMeta(collectionName, schemaName string) (func(), error) {
+	return acquireLock(undo.Key(schemaName, collectionName)+".meta", filepath.Join(schemaDir(schemaName), collectionName+".meta.lock"), "the metadata of collection "+collectionName)
+}
+
+// acquireLock takes the semaphore for key and the advisory lock on path,
+// within the lock timeout. what names the lock in timeout errors.
+func acquireLock(key, path, what string) (func(), error) {
+	writeLocksMu.Lock()
+	sem, ok := writeLocks[key]
+	if !ok {
+		sem = make(chan struct{}, 1)
+		writeLocks[key] = sem
+	}
+	writeLocksMu.Unlock()
+
+	timeout := lockTimeout()
+	deadline := time.Now().Add(timeout)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case sem <- struct{}{}:
+	case <-timer.C:
+		return nil, kerrors.New(kerrors.ErrLockTimeout, "timed out after %s waiting for %s", timeout, what)
+	}
+
+	unlockFile, err := filelock.LockTimeout(path, time.Until(deadline))
+	if err != nil {
+		<-sem
+		if errors.Is(err, filelock.ErrTimeout) {
+			return nil, kerrors.New(kerrors.ErrLockTimeout, "timed out after %s waiting for %s", timeout, what)
+		}
+		return nil, err
+	}
+	return func() {
+		unlockFile()
+		<-sem
+	}, nil
+}
+
+func lockPath(dir, collectionName string) string {
+	return filepath.Join(dir, collectionName+".lock")
+}
+
+// collectionReadError tags a missing collection file so the API can report it.
+func collectionReadError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var kerr *kerrors.Error
+	if errors.As(err, &kerr) {
+		return err
+	}
+	if os.IsNotExist(err) {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			dir := filepath.Dir(pathErr.Path)
+			if _, statErr := os.Stat(dir); os.IsNotExist(statErr) && filepath.Dir(dir) == schemaDir("") {
+				return kerrors.New(kerrors.ErrSchemaNotFound, "schema %s does not exist", filepath.Base(dir))
+			}
+		}
+		return kerrors.New(kerrors.ErrCollectionNotFound, "failed to read collection file: %v", err)
+	}
+	return fmt.Errorf("failed to read collection file: %v", err)
+}
+
+func isReservedField(k string) bool {
+	switch k {
+	case "_id", "createdAt", "updatedAt", "_version", "_locked_by", "_lock_expires_at":
+		return true
+	}
+	return false
+}
+
+// userFields returns record without its reserved fields.
+func userFields(record types.Record) types.Record {
+	fields := make(types.Record, len(record))
+	for k, v := range record {
+		if !isReservedField(k) {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// newRecord stamps fresh metadata onto user supplied fields.
+func newRecord(inputData map[string]interface{}) types.Record {
+	now := time.Now().UTC().Format(time.RFC3339)
+	record := types.Record{
+		"_id":       kid.GenerateID(),
+		"createdAt": now,
+		"updatedAt": now,
+		"_version":  float64(0),
+	}
+	for k, v := range inputData {
+		if !isReservedField(k) {
+			record[k] = v
+		}
+	}
+	return record
+}
+
+// readRecords decrypts a collection from the primary and returns its records
+// along with the key.
+func readRecords(collectionName, schemaName string) ([]types.Record, []byte, error) {
+	return readRecordsFrom(CollectionPath(schemaName, collectionName, true), collectionName, schemaName)
+}
+
+// ReadCollection returns the records of a collection for read-only use,
+// preferring the replica when one is configured.
+// Results are cached until the encrypted file changes, so callers must not
+// modify the returned records.
+func ReadCollection(collectionName, schemaName string) (records []types.Record, err error) {
+	defer observe("read", schemaName, collectionName, time.Now(), &err)
+	return readCached(CollectionPath(schemaName, collectionName, false), collectionName, schemaName)
+}
+
+// ReadPrimaryCollection is ReadCollection without the replica, for reads
+// that must observe the caller's own writes.
+func ReadPrimaryCollection(collectionName, schemaName string) (records []types.Record, err error) {
+	defer observe("read", schemaName, collectionName, time.Now(), &err)
+	return readCached(CollectionPath(schemaName, collectionName, true), collectionName, schemaName)
+}
+
+const (
+	ConsistencyStrong   = "strong"
+	ConsistencyEventual = "eventual"
+)
+
+// ReadCollectionConsistent is ReadCollection at the given consistency level,
+// or the configured one when level is empty. Strong reads go to the primary
+// and always see the latest write; eventual reads return whatever is cached,
+// even if the file has changed since.
+func ReadCollectionConsistent(collectionName, schemaName, level string) ([]types.Record, error) {
+	if level == "" {
+		level = currentConfig().Consistency
+	}
+	switch level {
+	case ConsistencyStrong:
+		return ReadPrimaryCollection(collectionName, schemaName)
+	case ConsistencyEventual:
+		dir := CollectionPath(schemaName, collectionName, false)
+		if records, ok := currentCache().Peek(filepath.Join(dir, collectionName+".txt")); ok {
+			touchAccess(collectionName, schemaName)
+			return records, nil
+		}
+	}
+	return ReadCollection(collectionName, schemaName)
+}
+
+func readCached(dir, collectionName, schemaName string) ([]types.Record, error) {
+	collectionPath := filepath.Join(dir, collectionName+".txt")
+
+	encryptedData, err := readLocked(dir, collectionName, schemaName)
+	if err != nil {
+		return nil, collectionReadError(err)
+	}
+	sum := sha256.Sum256(encryptedData)
+	etag := hex.EncodeToString(sum[:])
+
+	records, ok := currentCache().Get(collectionPath, etag)
+	if !ok {
+		if records, _, err = decodeRecords(dir, collectionName, schemaName, encryptedData); err != nil {
+			return nil, err
+		}
+		currentCache().Set(collectionPath, etag, records)
+	}
+	touchAccess(collectionName, schemaName)
+	return records, nil
+}
+
+// readLocked reads a collection file under a shared lock so it never sees a
+// write from another process half done. Replicas are read without locking.
+func readLocked(dir, collectionName, schemaName string) ([]byte, error) {
+	collectionPath := filepath.Join(dir, collectionName+".txt")
+	if dir != dataDir(collectionName, schemaName) {
+		return os.ReadFile(collectionPath)
+	}
+	if _, err := os.Stat(collectionPath); err != nil {
+		return nil, err
+	}
+	timeout := lockTimeout()
+	unlock, err := filelock.RLockTimeout(lockPath(schemaDir(schemaName), collectionName), timeout)
+	if err != nil {
+		if errors.Is(err, filelock.ErrTimeout) {
+			return nil, kerrors.New(kerrors.ErrLockTimeout, "timed out after %s waiting for a read lock on collection %s", timeout, collectionName)
+		}
+		return nil, err
+	}
+	defer unlock()
+	return os.ReadFile(collectionPath)
+}
+
+func readRecordsFrom(dir, collectionName, schemaName string) ([]types.Record, []byte, error) {
+	collectionPath := filepath.Join(dir, collectionName+".txt")
+
+	encryptedData, err := os.ReadFile(collectionPath)
+	if err != nil {
+		return nil, nil, collectionReadError(err)
+	}
+	return decodeRecords(dir, collectionName, schemaName, encryptedData)
+}
+
+func decodeRecords(dir, collectionName, schemaName string, encryptedData []byte) ([]types.Record, []byte, error) {
+	decrypted, key, err := decryptCollectionData(dir, collectionName, schemaName, encryptedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []types.Record
+	if err := unmarshalJSON(decrypted, &records); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse collection JSON: %v", err)
+	}
+	slog.Debug("decrypted collection", "schema", schemaName, "collection", collectionName, "records", len(records))
+	return records, key, nil
+}
+
+// saveCollection replaces the collection file, remembering the previous
+// contents so the write can be undone and keeping a history snapshot of the
+// new contents.
+func saveCollection(collectionName, schemaName, op string, recordCount int, encrypted []byte) error {
+	if err := checkWritable(collectionName, schemaName); err != nil {
+		return err
+	}
+	slog.Debug("writing collection", "operation", op, "schema", schemaName, "collection", collectionName, "records", recordCount)
+
+	collectionPath := filepath.Join(dataDir(collectionName, schemaName), collectionName+".txt")
+
+	before, err := os.ReadFile(collectionPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read collection file: %v", err)
+	}
+
+	if err := writeCollectionAtomic(collectionPath, encrypted); err != nil {
+		return fmt.Errorf("failed to write collection file: %v", err)
+	}
+	currentCache().Invalidate(collectionPath)
+	touchAccess(collectionName, schemaName)
+
+	if err := history.SaveSnapshot(schemaDir(schemaName), collectionName, op, recordCount, encrypted, historyLimit()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if before != nil {
+		undo.Push(undo.Key(schemaName, collectionName), types.UndoEntry{
+			Op:        op,
+			Before:    before,
+			After:     encrypted,
+			Timestamp: time.Now().UTC(),
+		})
+	}
+	return nil
+}
+
+// writeRecords encrypts records with key and replaces the collection file.
+func writeRecords(collectionName, schemaName, op string, records []types.Record, key []byte) error {
+	dataToEncrypt, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON data: %v", err)
+	}
+
+	encrypted, err := encryptCollectionData(collectionName, schemaName, dataToEncrypt, key)
+	if err != nil {
+		return err
+	}
+
+	return saveCollection(collectionName, schemaName, op, len(records), encrypted)
+}
+
+// createCollection writes records to a new collection with a fresh key.
+func createCollection(collectionName, schemaName string, records []types.Record) error {
+	dir := schemaDir(schemaName)
+	if err := mkdirAll(dir); err != nil {
+		return err
+	}
+
+	collectionPath := filepath.Join(dir, collectionName+".txt")
+	if _, err := os.Stat(collectionPath); err == nil {
+		return kerrors.New(kerrors.ErrCollectionExists, "collection %s already exists in %s", collectionName, dir)
+	}
+
+	key, derived, err := newCollectionKey(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+
+	if records == nil {
+		records = []types.Record{}
+	}
+	if err := writeRecords(collectionName, schemaName, "insert", records, key); err != nil {
+		return err
+	}
+	if derived {
+		markCreated(collectionName, schemaName)
+		return nil
+	}
+
+	keyPath := filepath.Join(dir, collectionName+".key")
+	if err := writeCollectionAtomic(keyPath, key); err != nil {
+		os.Remove(collectionPath)
+		return fmt.Errorf("failed to write key file: %v", err)
+	}
+	markCreated(collectionName, schemaName)
+	return nil
+}
+
+// CopyCollection copies the records of a collection into a new collection,
+// possibly in another schema. The copy gets its own key.
+func CopyCollection(srcName, srcSchema, dstName, dstSchema string) error {
+	if err := ValidateNames(dstSchema, dstName); err != nil {
+		return err
+	}
+	if collectionExists(dstName, dstSchema) {
+		return kerrors.New(kerrors.ErrCollectionExists, "collection %s already exists in %s", dstName, schemaDir(dstSchema))
+	}
+
+	records, _, err := readRecords(srcName, srcSchema)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockCollection(dstName, dstSchema)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := createCollection(dstName, dstSchema, records); err != nil {
+		return err
+	}
+	fmt.Printf("Copied collection %s to %s (%d records)\n", srcName, filepath.Join(schemaDir(dstSchema), dstName), len(records))
+	return nil
+}
+
+// RenameCollection renames a collection along with its key and sidecar
+// files. newName must not already exist.
+func RenameCollection(oldName, newName, schemaName string) error {
+	if err := ValidateCollectionName(newName); err != nil {
+		return err
+	}
+	if newName == oldName {
+		return kerrors.New(kerrors.ErrInvalidRequest, "collection %s already has that name", oldName)
+	}
+	if !collectionExists(oldName, schemaName) {
+		return kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", oldName, schemaDir(schemaName))
+	}
+
+	unlock, err := lockCollection(oldName, schemaName)
+	if err != nil {
+		return err
+	}
+	unlockNew, err := lockCollection(newName, schemaName)
+	if err != nil {
+		unlock()
+		return err
+	}
+	err = checkWritable(oldName, schemaName)
+	if err == nil {
+		err = renameCollectionFiles(oldName, newName, schemaName)
+	}
+	unlockNew()
+	unlock()
+	if err != nil {
+		return err
+	}
+	removeLockFiles(oldName, schemaName)
+	fmt.Printf("Renamed collection %s to %s\n", oldName, newName)
+	return nil
+}
+
+// renameCollectionFiles moves a collection and its sidecar files to newName.
+func renameCollectionFiles(oldName, newName, schemaName string) error {
+	dir := schemaDir(schemaName)
+	if collectionExists(newName, schemaName) {
+		return kerrors.New(kerrors.ErrCollectionExists, "collection %s already exists in %s", newName, dir)
+	}
+	if !collectionExists(oldName, schemaName) {
+		return kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", oldName, dir)
+	}
+
+	data := dataDir(oldName, schemaName)
+	if usesDerivedKey(oldName, schemaName) {
+		return renameDerivedCollection(data, oldName, newName, schemaName)
+	}
+	oldKey := filepath.Join(data, oldName+".key")
+	if err := os.Rename(oldKey, filepath.Join(data, newName+".key")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rename %s: %v", oldKey, err)
+	}
+	if err := os.Rename(filepath.Join(data, oldName+".txt"), filepath.Join(data, newName+".txt")); err != nil {
+		return fmt.Errorf("failed to rename collection file: %v", err)
+	}
+	if err := renameSidecars(dir, oldName, newName); err != nil {
+		return err
+	}
+	undo.Clear(undo.Key(schemaName, oldName))
+	return history.Rename(dir, oldName, newName)
+}
+
+func renameSidecars(dir, oldName, newName string) error {
+	for _, ext := range sidecarSuffixes {
+		// Lock files belong to the name; the caller holds both sets.
+		if slices.Contains(lockSuffixes, ext) {
+			continue
+		}
+		oldPath := filepath.Join(dir, oldName+ext)
+		if err := os.Rename(oldPath, filepath.Join(dir, newName+ext)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rename %s: %v", oldPath, err)
+		}
+	}
+	return nil
+}
+
+// renameDerivedCollection renames a collection whose key is derived from
+// its name, which means re-encrypting it. History snapshots cannot be
+// carried over and are dropped.
+func renameDerivedCollection(data, oldName, newName, schemaName string) error {
+	dir := schemaDir(schemaName)
+	oldPath := filepath.Join(data, oldName+".txt")
+	encrypted, err := os.ReadFile(oldPath)
+	if err != nil {
+		return collectionReadError(err)
+	}
+	decrypted, oldKey, err := decryptCollectionData(data, oldName, schemaName, encrypted)
+	if err != nil {
+		return err
+	}
+	newKey, err := deriveCollectionKey(newName, schemaName)
+	if err != nil {
+		return err
+	}
+	reencrypted, err := helper.Encrypt(decrypted, newKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt data: %v", err)
+	}
+	if err := writeCollectionAtomic(filepath.Join(data, newName+".txt"), []byte(derivedKeyHeader+reencrypted)); err != nil {
+		return fmt.Errorf("failed to write collection file: %v", err)
+	}
+	if err := os.Remove(oldPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %v", oldPath, err)
+	}
+	currentCache().Invalidate(oldPath)
+
+	if err := renameSidecars(dir, oldName, newName); err != nil {
+		return err
+	}
+	if base, err := os.ReadFile(forkBasePath(newName, schemaName)); err == nil {
+		if err := rekeyFile(forkBasePath(newName, schemaName), base, oldKey, newKey); err != nil {
+			return err
+		}
+	}
+	undo.Clear(undo.Key(schemaName, oldName))
+	return history.Remove(dir, oldName)
+}
+
+func collectionExists(collectionName, schemaName string) bool {
+	_, err := os.Stat(filepath.Join(dataDir(collectionName, schemaName), collectionName+".txt"))
+	return err == nil
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"kite/src/cache"
+	kconfig "kite/src/config"
+	"kite/src/history"
+	"kite/src/types"
+)
+
+var (
+	configMu        sync.RWMutex
+	config          types.DBConfig
+	collectionCache = cache.New(cache.DefaultCapacity)
+	fileMode        = kconfig.DefaultFilePermission
+	dirMode         = kconfig.DefaultDirPermission
+)
+
+// Configure sets the server settings the controller functions honour.
+func Configure(cfg types.DBConfig) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	config = cfg
+	if cfg.CacheCapacity > 0 {
+		collectionCache = cache.New(cfg.CacheCapacity)
+	}
+	// Invalid modes are rejected when the server starts; keep the defaults.
+	if mode, err := kconfig.ParsePermission(cfg.FilePermission, kconfig.DefaultFilePermission); err == nil {
+		fileMode = mode
+	}
+	if mode, err := kconfig.ParsePermission(cfg.DirPermission, kconfig.DefaultDirPermission); err == nil {
+		dirMode = mode
+	}
+	history.SetPermissions(fileMode, dirMode)
+}
+
+// FileMode is the permission given to collection files.
+func FileMode() os.FileMode {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return fileMode
+}
+
+// DirMode is the permission given to schema directories.
+func DirMode() os.FileMode {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return dirMode
+}
+
+// writeFile writes data with the configured file mode, fixing the mode of
+// files that already existed or were narrowed by the umask.
+func writeFile(path string, data []byte) error {
+	mode := FileMode()
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, mode)
+}
+
+// writeTemp writes the temporary file in writeCollectionAtomic. Tests
+// replace it to fail part way through a write.
+var writeTemp = (*os.File).Write
+
+// writeCollectionAtomic replaces path by writing and syncing a sibling .tmp
+// file and renaming it over path, so a crash mid-write leaves either the old
+// or the new contents but never a torn file. Each call gets its own temp
+// file, so concurrent writers never share one.
+func writeCollectionAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	if _, err := writeTemp(f, data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, FileMode()); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// mkdirAll creates dir with the configured directory mode.
+func mkdirAll(dir string) error {
+	mode := DirMode()
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", dir, err)
+	}
+	if err := os.Chmod(dir, mode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %v", dir, err)
+	}
+	return nil
+}
+
+func currentCache() *cache.CollectionCache {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return collectionCache
+}
+
+func currentConfig() types.DBConfig {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+// unmarshalJSON is json.Unmarshal, except that numbers decode to
+// json.Number when use_json_number is set.
+func unmarshalJSON(data []byte, v interface{}) error {
+	if !currentConfig().UseJSONNumber {
+		return json.Unmarshal(data, v)
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return fmt.Errorf("invalid data after top-level value")
+	}
+	return nil
+}
+
+// CollectionPath returns the directory holding collectionName. Reads are
+// served from the replica when one is configured, except for collections
+// stored at a custom path; writes always go to the primary.
+func CollectionPath(schemaName, collectionName string, write bool) string {
+	dir := dataDir(collectionName, schemaName)
+	if replica := currentConfig().ReplicaDBDir; !write && replica != "" && dir == schemaDir(schemaName) {
+		return filepath.Join(replica, schemaName)
+	}
+	return dir
+}
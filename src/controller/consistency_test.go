@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestReadCollectionConsistent(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+	if err := s.InsertRecord(context.Background(), "notes", `{"title":"b"}`, "public"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leave a cache entry behind that no longer matches the file.
+	path := filepath.Join(CollectionPath("public", "notes", false), "notes.txt")
+	currentCache().Set(path, "stale", []types.Record{{"title": "a"}})
+
+	records, err := ReadCollectionConsistent("notes", "public", ConsistencyEventual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("eventual read returned %d records, want the 1 cached", len(records))
+	}
+
+	records, err = ReadCollectionConsistent("notes", "public", ConsistencyStrong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("strong read returned %d records, want the 2 on disk", len(records))
+	}
+
+	// The strong read replaced the stale entry, so eventual reads catch up.
+	records, err = ReadCollectionConsistent("notes", "public", ConsistencyEventual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("eventual read after a strong read returned %d records, want 2", len(records))
+	}
+
+	if _, err := ReadCollectionConsistent("missing", "public", ConsistencyEventual); err == nil {
+		t.Error("eventual read of a missing collection succeeded")
+	}
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"sync"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+// CrossSchemaQuery reads collectionName from every schema in parallel,
+// filters each, and merges the results in schema order. A record whose _id
+// was already seen in an earlier schema is dropped. Schemas that do not
+// have the collection are skipped.
+func CrossSchemaQuery(schemas []string, collectionName string, filter map[string]string, limit int) ([]types.Record, error) {
+	for _, schemaName := range schemas {
+		if err := ValidateNames(schemaName, collectionName); err != nil {
+			return nil, err
+		}
+	}
+
+	var exprs []types.FilterExpression
+	for field, value := range filter {
+		exprs = append(exprs, types.FilterExpression{Field: field, Op: "eq", Value: value})
+	}
+
+	results := make([][]types.Record, len(schemas))
+	errs := make([]error, len(schemas))
+	var wg sync.WaitGroup
+	for i, schemaName := range schemas {
+		wg.Add(1)
+		go func(i int, schemaName string) {
+			defer wg.Done()
+			records, err := ReadCollection(collectionName, schemaName)
+			if err != nil {
+				if kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+					errs[i] = err
+				}
+				return
+			}
+			results[i] = filterRecords(records, exprs)
+		}(i, schemaName)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	merged := []types.Record{}
+	seen := make(map[interface{}]bool)
+	for _, records := range results {
+		for _, record := range records {
+			if seen[record["_id"]] {
+				continue
+			}
+			seen[record["_id"]] = true
+			merged = append(merged, record)
+			if limit > 0 && len(merged) == limit {
+				return merged, nil
+			}
+		}
+	}
+	return merged, nil
+}
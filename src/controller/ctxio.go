@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// contextReader fails the next Read once ctx is done, so long reads stop
+// soon after the caller goes away.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// readFileContext is os.ReadFile with cancellation between chunks.
+func readFileContext(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(contextReader{ctx: ctx, r: f})
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelledWritesLeaveCollection(t *testing.T) {
+	s := newTestStore(t)
+	var records []string
+	for i := 0; i < 5000; i++ {
+		records = append(records, fmt.Sprintf(`{"n":%d,"body":"%s"}`, i, strings.Repeat("x", 64)))
+	}
+	addTestCollection(t, s, "big", "["+strings.Join(records, ",")+"]")
+	stored, err := ReadCollection("big", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := stored[0]["_id"].(string)
+	path := filepath.Join(schemaDir("public"), "big.txt")
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops := map[string]func(ctx context.Context) error{
+		"insert": func(ctx context.Context) error { return s.InsertRecord(ctx, "big", `{"n":-1}`, "public") },
+		"edit": func(ctx context.Context) error {
+			_, err := s.EditCollection(ctx, "big", id, `{"n":-1}`, "public", "")
+			return err
+		},
+		"move": func(ctx context.Context) error { return s.MoveRecord(ctx, "big", id, "public", "") },
+		"pull": func(ctx context.Context) error { return s.PullCollection(ctx, "big", "public") },
+	}
+	for name, op := range ops {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		time.Sleep(time.Millisecond)
+		err := op(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s with an expired context = %v, want %v", name, err, context.DeadlineExceeded)
+		}
+		if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+			t.Fatalf("%s with an expired context changed the collection", name)
+		}
+	}
+
+	if err := s.InsertRecord(context.Background(), "big", `{"n":-1}`, "public"); err != nil {
+		t.Fatalf("insert with a live context: %v", err)
+	}
+}
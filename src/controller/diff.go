@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"fmt"
+
+	kdiff "kite/src/diff"
+	"kite/src/types"
+)
+
+// DiffCollections compares two collections record by record using _id.
+func DiffCollections(collA, schemaA, collB, schemaB string) (types.CollectionDiff, error) {
+	var diff types.CollectionDiff
+	if err := ValidateNames(schemaB, collB); err != nil {
+		return diff, err
+	}
+
+	recordsA, err := ReadCollection(collA, schemaA)
+	if err != nil {
+		return diff, fmt.Errorf("collection %s: %w", collA, err)
+	}
+	recordsB, err := ReadCollection(collB, schemaB)
+	if err != nil {
+		return diff, fmt.Errorf("collection %s: %w", collB, err)
+	}
+
+	byID := make(map[interface{}]types.Record, len(recordsB))
+	for _, record := range recordsB {
+		byID[record["_id"]] = record
+	}
+
+	inA := make(map[interface{}]bool, len(recordsA))
+	for _, record := range recordsA {
+		inA[record["_id"]] = true
+		other, ok := byID[record["_id"]]
+		if !ok {
+			diff.Removed = append(diff.Removed, record)
+			continue
+		}
+		if changes := kdiff.RecordDiff(record, other); len(changes) > 0 {
+			id, _ := record["_id"].(string)
+			diff.Modified = append(diff.Modified, types.RecordDiff{ID: id, Changes: changes})
+		} else {
+			diff.Unchanged++
+		}
+	}
+
+	for _, record := range recordsB {
+		if !inA[record["_id"]] {
+			diff.Added = append(diff.Added, record)
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiffCollections(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	addTestCollection(t, s, "backup", `[{"title":"a"},{"title":"b"},{"title":"c"}]`)
+	if err := CopyCollection("backup", "public", "live", "public"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadCollection("live", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[interface{}]string{}
+	for _, record := range records {
+		ids[record["title"]], _ = record["_id"].(string)
+	}
+	if _, err := s.EditCollection(ctx, "live", ids["a"], `{"title":"changed"}`, "public", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MoveRecord(ctx, "live", ids["b"], "public", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertRecord(ctx, "live", `{"title":"d"}`, "public"); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := DiffCollections("backup", "public", "live", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0]["title"] != "d" {
+		t.Errorf("added = %v, want record d", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0]["_id"] != ids["b"] {
+		t.Errorf("removed = %v, want record b", diff.Removed)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("unchanged = %d, want 1", diff.Unchanged)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0].ID != ids["a"] {
+		t.Fatalf("modified = %+v, want record a", diff.Modified)
+	}
+	found := false
+	for _, change := range diff.Modified[0].Changes {
+		if change.Field == "title" && change.Before == "a" && change.After == "changed" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("changes to record a = %+v, want title a -> changed", diff.Modified[0].Changes)
+	}
+
+	if _, err := DiffCollections("backup", "public", "missing", "public"); err == nil {
+		t.Error("diff against a missing collection succeeded")
+	}
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/history"
+)
+
+// DropCollection deletes a collection and its sidecar files under the
+// collection lock, so it cannot interleave with a write. Read-only
+// collections are refused.
+func DropCollection(collectionName, schemaName string) (err error) {
+	defer observe("drop_collection", schemaName, collectionName, time.Now(), &err)
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	err = checkWritable(collectionName, schemaName)
+	if err == nil {
+		err = dropCollectionLocked(collectionName, schemaName)
+	}
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	removeLockFiles(collectionName, schemaName)
+	fmt.Printf("Dropped collection %s from %s\n", collectionName, schemaDir(schemaName))
+	return nil
+}
+
+// removeLockFiles deletes the lock files of a collection that no longer
+// exists. It runs after the locks are released, since removing a held lock
+// file fails on Windows.
+func removeLockFiles(collectionName, schemaName string) {
+	dir := schemaDir(schemaName)
+	for _, ext := range lockSuffixes {
+		os.Remove(filepath.Join(dir, collectionName+ext))
+	}
+}
+
+// dropCollectionLocked deletes everything but the lock files of a collection.
+// The caller holds the collection lock.
+func dropCollectionLocked(collectionName, schemaName string) error {
+	dir := schemaDir(schemaName)
+	data := dataDir(collectionName, schemaName)
+
+	collectionPath := filepath.Join(data, collectionName+".txt")
+	keyPath := filepath.Join(data, collectionName+".key")
+
+	if _, err := os.Stat(collectionPath); os.IsNotExist(err) {
+		return kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", collectionName, dir)
+	}
+
+	if err := os.Remove(collectionPath); err != nil {
+		return fmt.Errorf("failed to delete collection file: %v", err)
+	}
+
+	// Collections with a derived key have no key file.
+	if err := os.Remove(keyPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete key file: %v", err)
+	}
+
+	for _, ext := range sidecarSuffixes {
+		if slices.Contains(lockSuffixes, ext) {
+			continue
+		}
+		sidecar := filepath.Join(dir, collectionName+ext)
+		if err := os.Remove(sidecar); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %v", sidecar, err)
+		}
+	}
+
+	return history.Remove(dir, collectionName)
+}
+
+// DropSchema removes a schema. A schema that still has collections is only
+// removed with force, which drops them first.
+func DropSchema(schemaName string, force bool) error {
+	if err := ValidateSchemaName(schemaName); err != nil {
+		return err
+	}
+	dir := schemaDir(schemaName)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return kerrors.New(kerrors.ErrSchemaNotFound, "schema %s does not exist", schemaName)
+	}
+
+	collections, err := ListCollections(schemaName)
+	if err != nil {
+		return err
+	}
+	if len(collections) > 0 && !force {
+		return kerrors.New(kerrors.ErrSchemaNotEmpty, "schema %s still has %d collections", schemaName, len(collections))
+	}
+	for _, collectionName := range collections {
+		if err := DropCollection(collectionName, schemaName); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete schema directory: %v", err)
+	}
+	fmt.Printf("Dropped schema %s\n", schemaName)
+	return nil
+}
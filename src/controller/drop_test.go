@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/filelock"
+	"kite/src/types"
+)
+
+// TestInsertProcess is run as a child process by TestDropRacesInsertProcess.
+func TestInsertProcess(t *testing.T) {
+	root := os.Getenv("KITE_TEST_INSERT_ROOT")
+	if root == "" {
+		t.Skip("helper process")
+	}
+	cfg := types.DBConfig{DBPath: root}
+	Configure(cfg)
+	s := NewStore(cfg)
+	for i := 0; i < 200; i++ {
+		s.InsertRecord(context.Background(), "notes", fmt.Sprintf(`{"n":%d}`, i), "public")
+	}
+}
+
+func TestDropRacesInsertProcess(t *testing.T) {
+	s := newTestStore(t)
+	for round := 0; round < 5; round++ {
+		addTestCollection(t, s, "notes", `[{"_id":"1"}]`)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestInsertProcess$")
+		cmd.Env = append(os.Environ(), "KITE_TEST_INSERT_ROOT="+DBPath())
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Duration(round*20) * time.Millisecond)
+		if err := DropCollection("notes", "public"); err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The insert either landed before the drop, failed after it, or
+		// created the collection afresh; it never left a data file whose
+		// key was deleted.
+		if _, err := ReadCollection("notes", "public"); err != nil {
+			if kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+				t.Fatalf("round %d: collection left unreadable: %v", round, err)
+			}
+			continue
+		}
+		if err := DropCollection("notes", "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadLockTimeout(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"_id":"1"}]`)
+	Configure(types.DBConfig{DBPath: DBPath(), LockTimeout: "50ms"})
+	defer Configure(types.DBConfig{DBPath: DBPath()})
+
+	unlock, err := filelock.Lock(lockPath(schemaDir("public"), "notes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	start := time.Now()
+	_, err = ReadCollection("notes", "public")
+	if kerrors.Code(err) != kerrors.ErrLockTimeout {
+		t.Fatalf("ReadCollection under a held write lock = %v, want %s", err, kerrors.ErrLockTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("read waited %s despite a 50ms lock timeout", elapsed)
+	}
+}
+
+func TestDropCollectionRemovesLockFiles(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"_id":"1"}]`)
+	if err := SetReadOnly("notes", "public", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := DropCollection("notes", "public"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(schemaDir("public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		t.Errorf("left behind after drop: %s", entry.Name())
+	}
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"kite/src/types"
+)
+
+// DumpCollection decrypts a collection and writes it to w without trusting
+// its contents. Valid JSON is written indented. Otherwise the bytes up to
+// the first syntax error are written and the error gives its location.
+// forceRaw writes the decrypted bytes as they are, valid or not, so they can
+// be repaired by hand and imported again.
+func DumpCollection(collectionName, schemaName string, forceRaw bool, w io.Writer) error {
+	dir := dataDir(collectionName, schemaName)
+	encrypted, err := os.ReadFile(filepath.Join(dir, collectionName+".txt"))
+	if err != nil {
+		return collectionReadError(err)
+	}
+	decrypted, err := DecryptCollectionData(dir, collectionName, schemaName, encrypted)
+	if err != nil {
+		return err
+	}
+
+	if forceRaw {
+		if _, err := w.Write(decrypted); err != nil {
+			return fmt.Errorf("failed to write output: %v", err)
+		}
+		return nil
+	}
+
+	var records []types.Record
+	err = json.Unmarshal(decrypted, &records)
+	if err == nil {
+		var out bytes.Buffer
+		if err := json.Indent(&out, decrypted, "", "  "); err != nil {
+			return fmt.Errorf("failed to format JSON: %v", err)
+		}
+		out.WriteByte('\n')
+		_, err = out.WriteTo(w)
+		return err
+	}
+
+	offset := int64(len(decrypted))
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// Offset counts the byte that broke the parse; stop before it.
+		offset = syntaxErr.Offset - 1
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	if offset < 0 || offset > int64(len(decrypted)) {
+		offset = int64(len(decrypted))
+	}
+	w.Write(decrypted[:offset])
+	fmt.Fprintln(w)
+	line, col := position(decrypted, offset)
+	return fmt.Errorf("invalid JSON at byte %d (line %d, column %d): %v", offset, line, col, err)
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(data []byte, offset int64) (line, col int) {
+	line = 1 + bytes.Count(data[:offset], []byte("\n"))
+	col = int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+	return line, col
+}
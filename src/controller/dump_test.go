@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestPlaintext encrypts plaintext over an existing collection's data
+// file, so tests can plant JSON that the write path would never produce.
+func writeTestPlaintext(t *testing.T, collectionName, plaintext string) {
+	t.Helper()
+	key, err := readCollectionKey(collectionName, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encryptCollectionData(collectionName, "public", []byte(plaintext), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dataDir(collectionName, "public"), collectionName+".txt")
+	if err := os.WriteFile(path, encrypted, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDumpCollection(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+
+	var out bytes.Buffer
+	if err := DumpCollection("notes", "public", false, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"title": "a"`) {
+		t.Errorf("dump of a valid collection = %q, want indented JSON", out.String())
+	}
+
+	truncated := `[{"_id":"1","title":"a"},{"_id":"2","ti`
+	writeTestPlaintext(t, "notes", truncated)
+
+	out.Reset()
+	err := DumpCollection("notes", "public", false, &out)
+	if err == nil || !strings.Contains(err.Error(), "invalid JSON at byte") {
+		t.Fatalf("dump of a truncated collection = %v, want the error location", err)
+	}
+	if !strings.HasPrefix(out.String(), `[{"_id":"1","title":"a"}`) {
+		t.Errorf("dump wrote %q, want the bytes before the damage", out.String())
+	}
+
+	out.Reset()
+	if err := DumpCollection("notes", "public", true, &out); err != nil {
+		t.Fatalf("forced dump = %v", err)
+	}
+	if out.String() != truncated {
+		t.Errorf("forced dump = %q, want the raw plaintext %q", out.String(), truncated)
+	}
+
+	// Nothing is written when the file no longer decrypts.
+	path := filepath.Join(dataDir("notes", "public"), "notes.txt")
+	if err := os.WriteFile(path, []byte("not ciphertext"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := DumpCollection("notes", "public", true, &out); err == nil || out.Len() != 0 {
+		t.Errorf("dump of an undecryptable file = %v, wrote %q; want an error and no output", err, out.String())
+	}
+
+	if err := DumpCollection("missing", "public", true, &out); err == nil {
+		t.Error("dump of a missing collection succeeded")
+	}
+}
@@ -1,67 +1,97 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	kdiff "kite/src/diff"
 	"kite/src/types"
+	kerrors "kite/src/errors"
+	kid "kite/src/id"
 	"kite/src/helper"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
-func EditCollection(collectionName, id, jsonData, schemaName string) error {
-	dir := filepath.Join("..", "db")
-	if schemaName != "" {
-		dir = filepath.Join("..", "db", schemaName)
+// editCollection replaces the user fields of record id and returns how they
+// changed.
+func editCollection(ctx context.Context, collectionName, id, jsonData, schemaName, identity string) ([]types.FieldChange, error) {
+	if err := kid.Validate(id); err != nil {
+		return nil, err
+	}
+	dir := dataDir(collectionName, schemaName)
+
+	if eventSourced, err := isEventSourced(collectionName, schemaName); err != nil {
+		return nil, err
+	} else if eventSourced {
+		return nil, kerrors.New(kerrors.ErrEventSourced, "collection %s is event sourced; records cannot be edited", collectionName)
 	}
 
 	collectionPath := filepath.Join(dir, collectionName+".txt")
-	keyPath := filepath.Join(dir, collectionName+".key")
 
-	encryptedData, err := os.ReadFile(collectionPath)
+	unlock, err := lockCollection(collectionName, schemaName)
 	if err != nil {
-		return fmt.Errorf("failed to read collection file: %v", err)
+		return nil, err
 	}
+	defer unlock()
 
-	key, err := os.ReadFile(keyPath)
+	encryptedData, err := readFileContext(ctx, collectionPath)
 	if err != nil {
-		return fmt.Errorf("failed to read key file: %v", err)
+		return nil, collectionReadError(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	decrypted, err := helper.Decrypt(string(encryptedData), key)
+	decrypted, key, err := decryptCollectionData(dir, collectionName, schemaName, encryptedData)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt data: %v", err)
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	var records []types.Record
-	if err := json.Unmarshal(decrypted, &records); err != nil {
-		return fmt.Errorf("failed to parse collection JSON: %v", err)
+	if err := unmarshalJSON(decrypted, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse collection JSON: %v", err)
 	}
 
 	// Trim single quotes for Windows compatibility
 	cleanedJSON := strings.Trim(jsonData, "'\"")
 	var inputData map[string]interface{}
-	if err := json.Unmarshal([]byte(cleanedJSON), &inputData); err != nil {
-		return fmt.Errorf("failed to parse JSON data: %v", err)
+	if err := unmarshalJSON([]byte(cleanedJSON), &inputData); err != nil {
+		return nil, kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
 	}
 
+	var changes []types.FieldChange
 	found := false
 	now := time.Now().UTC().Format(time.RFC3339)
 	for i, record := range records {
 		if record["_id"] == id {
+			if err := checkLock(record, identity); err != nil {
+				return nil, err
+			}
+			version, err := helper.ToFloat64(record["_version"])
+			if err != nil {
+				return nil, fmt.Errorf("record %s has an invalid _version: %v", id, err)
+			}
 			newRecord := types.Record{
 				"_id":       id,
 				"createdAt": record["createdAt"],
 				"updatedAt": now,
-				"_version":  record["_version"].(float64) + 1,
+				"_version":  version + 1,
+			}
+			if owner, ok := record["_locked_by"]; ok {
+				newRecord["_locked_by"] = owner
+				newRecord["_lock_expires_at"] = record["_lock_expires_at"]
 			}
 			for k, v := range inputData {
-				if k != "_id" && k != "createdAt" && k != "updatedAt" && k != "_version" {
+				if !isReservedField(k) {
 					newRecord[k] = v
 				}
 			}
+			changes = kdiff.RecordDiff(userFields(record), userFields(newRecord))
 			records[i] = newRecord
 			found = true
 			break
@@ -69,23 +99,90 @@ func EditCollection(collectionName, id, jsonData, schemaName string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("record with _id %s not found", id)
+		return nil, kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
 	}
 
 	dataToEncrypt, err := json.Marshal(records)
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON data: %v", err)
+		return nil, fmt.Errorf("failed to marshal JSON data: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	encrypted, err := helper.Encrypt(dataToEncrypt, key)
+	encrypted, err := encryptCollectionData(collectionName, schemaName, dataToEncrypt, key)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt data: %v", err)
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	if err := os.WriteFile(collectionPath, []byte(encrypted), 0600); err != nil {
-		return fmt.Errorf("failed to write collection file: %v", err)
+	if err := saveCollection(collectionName, schemaName, "update", len(records), encrypted); err != nil {
+		return nil, err
 	}
 
 	fmt.Printf("Updated record %s in collection %s\n", id, collectionName)
-	return nil
-}
\ No newline at end of file
+	return changes, nil
+}
+
+// PatchRecord merges the fields in jsonData into record id, leaving fields it
+// does not mention untouched, and returns how they changed.
+func PatchRecord(ctx context.Context, collectionName, id, jsonData, schemaName, identity string) ([]types.FieldChange, error) {
+	if err := kid.Validate(id); err != nil {
+		return nil, err
+	}
+	if eventSourced, err := isEventSourced(collectionName, schemaName); err != nil {
+		return nil, err
+	} else if eventSourced {
+		return nil, kerrors.New(kerrors.ErrEventSourced, "collection %s is event sourced; records cannot be edited", collectionName)
+	}
+
+	var patch map[string]interface{}
+	if err := unmarshalJSON([]byte(strings.Trim(jsonData, "'\"")), &patch); err != nil {
+		return nil, kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	records, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i := recordIndex(records, id)
+	if i < 0 {
+		return nil, kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
+	}
+	record := records[i]
+	if err := checkLock(record, identity); err != nil {
+		return nil, err
+	}
+	version, err := helper.ToFloat64(record["_version"])
+	if err != nil {
+		return nil, fmt.Errorf("record %s has an invalid _version: %v", id, err)
+	}
+
+	before := userFields(record)
+	for k, v := range patch {
+		if !isReservedField(k) {
+			record[k] = v
+		}
+	}
+	record["_version"] = version + 1
+	record["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+
+	if err := writeRecords(collectionName, schemaName, "update", records, key); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Patched record %s in collection %s\n", id, collectionName)
+	return kdiff.RecordDiff(before, userFields(record)), nil
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"fmt"
+	"plugin"
+	"sort"
+	"sync"
+
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+// Reducer folds an aggregate's events, in sequence order, into its state.
+type Reducer func(events []map[string]interface{}) (map[string]interface{}, error)
+
+var (
+	reducersMu sync.RWMutex
+	reducers   = make(map[string]Reducer)
+)
+
+func RegisterReducer(name string, fn Reducer) {
+	reducersMu.Lock()
+	defer reducersMu.Unlock()
+	reducers[name] = fn
+}
+
+func LookupReducer(name string) (Reducer, bool) {
+	reducersMu.RLock()
+	defer reducersMu.RUnlock()
+	fn, ok := reducers[name]
+	return fn, ok
+}
+
+// LoadReducerPlugin registers the reducer exported by a Go plugin. The plugin
+// must export `var ReducerName string` and
+// `func Reduce([]map[string]interface{}) (map[string]interface{}, error)`.
+func LoadReducerPlugin(path string) (string, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open reducer plugin %s: %v", path, err)
+	}
+	nameSym, err := p.Lookup("ReducerName")
+	if err != nil {
+		return "", fmt.Errorf("reducer plugin %s: %v", path, err)
+	}
+	name, ok := nameSym.(*string)
+	if !ok {
+		return "", fmt.Errorf("reducer plugin %s: ReducerName must be a string", path)
+	}
+	reduceSym, err := p.Lookup("Reduce")
+	if err != nil {
+		return "", fmt.Errorf("reducer plugin %s: %v", path, err)
+	}
+	reduce, ok := reduceSym.(func([]map[string]interface{}) (map[string]interface{}, error))
+	if !ok {
+		return "", fmt.Errorf("reducer plugin %s: Reduce has the wrong signature", path)
+	}
+	RegisterReducer(*name, reduce)
+	return *name, nil
+}
+
+func isEventSourced(collectionName, schemaName string) (bool, error) {
+	schema, err := ReadCollectionSchema(collectionName, schemaName)
+	if err != nil {
+		return false, err
+	}
+	return schema.EventSourced, nil
+}
+
+// newEvent builds an event record from inputData, assigning the next sequence
+// number for its aggregate.
+func newEvent(existing []types.Record, inputData map[string]interface{}) (types.Record, error) {
+	eventType, _ := inputData["event_type"].(string)
+	aggregateID, _ := inputData["aggregate_id"].(string)
+	if eventType == "" || aggregateID == "" {
+		return nil, kerrors.New(kerrors.ErrSchemaValidation, "events require string event_type and aggregate_id fields")
+	}
+
+	sequence := float64(0)
+	for _, record := range existing {
+		if record["aggregate_id"] == aggregateID {
+			if seq, err := helper.ToFloat64(record["sequence"]); err == nil && seq > sequence {
+				sequence = seq
+			}
+		}
+	}
+
+	event := newRecord(inputData)
+	event["sequence"] = sequence + 1
+	if _, ok := event["payload"]; !ok {
+		event["payload"] = map[string]interface{}{}
+	}
+	return event, nil
+}
+
+// ReplayAggregate returns the events for aggregateID ordered by sequence.
+func ReplayAggregate(collectionName, schemaName, aggregateID string) ([]types.Record, error) {
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []types.Record{}
+	for _, record := range records {
+		if record["aggregate_id"] == aggregateID {
+			events = append(events, record)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, _ := helper.ToFloat64(events[i]["sequence"])
+		b, _ := helper.ToFloat64(events[j]["sequence"])
+		return a < b
+	})
+	return events, nil
+}
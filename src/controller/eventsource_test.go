@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+func TestNewEventSequenceWithJSONNumbers(t *testing.T) {
+	existing := []types.Record{
+		{"aggregate_id": "a", "sequence": json.Number("1")},
+		{"aggregate_id": "a", "sequence": json.Number("2")},
+		{"aggregate_id": "b", "sequence": json.Number("7")},
+	}
+	event, err := newEvent(existing, map[string]interface{}{"event_type": "renamed", "aggregate_id": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := event["sequence"].(float64); got != 3 {
+		t.Errorf("sequence = %v, want 3", event["sequence"])
+	}
+}
+
+func TestReplayRebuildsEachAggregate(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "ledger", "")
+	if err := WriteCollectionSchema("ledger", "public", types.CollectionSchema{EventSourced: true}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 1; i <= 10; i++ {
+		aggregate := "a"
+		if i%2 == 0 {
+			aggregate = "b"
+		}
+		event := fmt.Sprintf(`{"event_type":"deposited","aggregate_id":%q,"payload":{"amount":%d}}`, aggregate, i)
+		if err := s.InsertRecord(ctx, "ledger", event, "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	RegisterReducer("balance", func(events []map[string]interface{}) (map[string]interface{}, error) {
+		total := 0.0
+		for _, event := range events {
+			amount, err := helper.ToFloat64(event["payload"].(map[string]interface{})["amount"])
+			if err != nil {
+				return nil, err
+			}
+			total += amount
+		}
+		return map[string]interface{}{"balance": total}, nil
+	})
+	balance, ok := LookupReducer("balance")
+	if !ok {
+		t.Fatal("reducer not registered")
+	}
+	for aggregate, want := range map[string]float64{"a": 1 + 3 + 5 + 7 + 9, "b": 2 + 4 + 6 + 8 + 10} {
+		events, err := ReplayAggregate("ledger", "public", aggregate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 5 {
+			t.Fatalf("aggregate %s: %d events, want 5", aggregate, len(events))
+		}
+		folded := make([]map[string]interface{}, len(events))
+		for i, event := range events {
+			if event["aggregate_id"] != aggregate {
+				t.Errorf("aggregate %s: replay returned an event for %v", aggregate, event["aggregate_id"])
+			}
+			if seq, _ := helper.ToFloat64(event["sequence"]); seq != float64(i+1) {
+				t.Errorf("aggregate %s: event %d has sequence %v", aggregate, i, event["sequence"])
+			}
+			folded[i] = event
+		}
+		state, err := balance(folded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state["balance"] != want {
+			t.Errorf("aggregate %s: balance = %v, want %v", aggregate, state["balance"], want)
+		}
+	}
+
+	events, _ := ReplayAggregate("ledger", "public", "a")
+	id, _ := events[0]["_id"].(string)
+	if _, err := s.EditCollection(ctx, "ledger", id, `{"payload":{}}`, "public", ""); kerrors.Code(err) != kerrors.ErrEventSourced {
+		t.Errorf("edit of an event = %v, want %s", err, kerrors.ErrEventSourced)
+	}
+}
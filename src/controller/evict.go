@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"kite/src/types"
+)
+
+const (
+	EvictionReject = "reject"
+	EvictionLRU    = "lru"
+)
+
+// EvictLRU appends newRecord, first removing the least recently updated
+// records until there is room for it within maxRecords. Records with
+// _pinned: true are never evicted, so the result can still exceed
+// maxRecords when too many records are pinned.
+func EvictLRU(records []types.Record, newRecord types.Record, maxRecords int) []types.Record {
+	excess := len(records) + 1 - maxRecords
+	if maxRecords <= 0 || excess <= 0 {
+		return append(records, newRecord)
+	}
+
+	var candidates []int
+	for i, record := range records {
+		if pinned, _ := record["_pinned"].(bool); !pinned {
+			candidates = append(candidates, i)
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return updatedAt(records[candidates[a]]).Before(updatedAt(records[candidates[b]]))
+	})
+	if excess > len(candidates) {
+		excess = len(candidates)
+	}
+
+	evict := make(map[int]bool, excess)
+	for _, i := range candidates[:excess] {
+		evict[i] = true
+	}
+	kept := make([]types.Record, 0, len(records)-excess+1)
+	for i, record := range records {
+		if !evict[i] {
+			kept = append(kept, record)
+		}
+	}
+	return append(kept, newRecord)
+}
+
+func updatedAt(record types.Record) time.Time {
+	s, _ := record["updatedAt"].(string)
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
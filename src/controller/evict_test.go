@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestEvictLRU(t *testing.T) {
+	records := []types.Record{
+		{"_id": "a", "updatedAt": "2024-01-03T00:00:00Z"},
+		{"_id": "b", "updatedAt": "2024-01-01T00:00:00Z", "_pinned": true},
+		{"_id": "c", "updatedAt": "2024-01-02T00:00:00Z"},
+	}
+	got := EvictLRU(records, types.Record{"_id": "d"}, 3)
+	var ids []string
+	for _, record := range got {
+		ids = append(ids, record["_id"].(string))
+	}
+	if fmt.Sprint(ids) != "[a b d]" {
+		t.Errorf("kept %v, want [a b d]: c is the oldest unpinned record", ids)
+	}
+
+	pinned := []types.Record{{"_id": "a", "_pinned": true}}
+	if got := EvictLRU(pinned, types.Record{"_id": "b"}, 1); len(got) != 2 {
+		t.Errorf("evicted a pinned record: %v", got)
+	}
+}
+
+func TestInsertEvictsOldestRecord(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	addTestCollection(t, s, "events", `[{"n":1}]`)
+	if err := WriteCollectionSchema("events", "public", types.CollectionSchema{MaxRecords: 10, EvictionPolicy: EvictionLRU}); err != nil {
+		t.Fatal(err)
+	}
+	for n := 2; n <= 11; n++ {
+		if err := s.InsertRecord(ctx, "events", fmt.Sprintf(`{"n":%d}`, n), "public"); err != nil {
+			t.Fatalf("insert %d: %v", n, err)
+		}
+	}
+
+	records, err := ReadCollection("events", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 10 {
+		t.Fatalf("collection holds %d records, want 10", len(records))
+	}
+	for i, record := range records {
+		if record["n"] != float64(i+2) {
+			t.Errorf("record %d = %v, want n=%d", i, record, i+2)
+		}
+	}
+
+	if err := WriteCollectionSchema("events", "public", types.CollectionSchema{MaxRecords: 10, EvictionPolicy: "fifo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertRecord(ctx, "events", `{"n":12}`, "public"); err == nil {
+		t.Error("insert with an unknown eviction policy succeeded")
+	}
+}
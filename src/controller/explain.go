@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"fmt"
+
+	"kite/src/types"
+)
+
+// NoIndex is the index_used of a plan that scans the whole collection.
+const NoIndex = "none"
+
+// ExplainQuery runs a filtered read and reports how many records survive
+// each stage instead of returning them. Collections have no field indexes,
+// so every plan scans the collection.
+func ExplainQuery(collectionName, schemaName string, exprs []types.FilterExpression, sort, order string, limit, offset int) (types.QueryPlan, error) {
+	plan := types.QueryPlan{FilterExpressions: []types.FilterExpression{}, IndexUsed: NoIndex}
+
+	order, err := checkQuery(order, limit, offset)
+	if err != nil {
+		return plan, err
+	}
+
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return plan, err
+	}
+
+	plan.TotalRecords = len(records)
+	plan.Plan = append(plan.Plan, "scan collection")
+
+	if len(exprs) > 0 {
+		plan.FilterApplied = true
+		plan.FilterExpressions = exprs
+		plan.Plan = append(plan.Plan, "apply filter")
+	}
+	records = filterRecords(records, exprs)
+	plan.AfterFilter = len(records)
+
+	plan.AfterSort = "skipped"
+	if sort != "" {
+		sortRecords(records, sort, order)
+		plan.AfterSort = "applied"
+		plan.Plan = append(plan.Plan, fmt.Sprintf("sort by %s %s", sort, order))
+	}
+
+	if limit > 0 || offset > 0 {
+		plan.Plan = append(plan.Plan, fmt.Sprintf("paginate offset=%d limit=%d", offset, limit))
+	}
+	plan.AfterPagination = len(paginate(records, limit, offset))
+
+	return plan, nil
+}
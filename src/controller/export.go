@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"kite/src/types"
+)
+
+// WriteCollectionJSON writes the decrypted records of a collection to w as
+// an indented JSON array.
+func WriteCollectionJSON(collectionName, schemaName string, w io.Writer) error {
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	if records == nil {
+		records = []types.Record{}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON data: %v", err)
+	}
+	data = append(data, '\n')
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	return nil
+}
+
+// csvColumns returns the metadata columns followed by every other field
+// found in records, sorted.
+func csvColumns(records []types.Record) []string {
+	columns := []string{"_id", "createdAt", "updatedAt", "_version"}
+	seen := make(map[string]bool)
+	for _, c := range columns {
+		seen[c] = true
+	}
+	var fields []string
+	for _, record := range records {
+		for k := range record {
+			if !seen[k] {
+				seen[k] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return append(columns, fields...)
+}
+
+func csvCell(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WriteCollectionCSV writes the records of a collection to w as CSV with a
+// header row. Missing fields are left empty and nested values are written
+// as JSON.
+func WriteCollectionCSV(collectionName, schemaName string, w io.Writer) error {
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+
+	columns := csvColumns(records)
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	row := make([]string, len(columns))
+	for _, record := range records {
+		for i, column := range columns {
+			cell, err := csvCell(record[column])
+			if err != nil {
+				return fmt.Errorf("failed to encode field %s of record %v: %v", column, record["_id"], err)
+			}
+			row[i] = cell
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	return nil
+}
+
+// ExportCollection writes a collection as plain JSON to outputPath, or to
+// stdout when outputPath is empty.
+func ExportCollection(collectionName, schemaName, outputPath string) error {
+	return exportTo(outputPath, func(w io.Writer) error {
+		return WriteCollectionJSON(collectionName, schemaName, w)
+	})
+}
+
+// ExportCSV is ExportCollection in CSV format.
+func ExportCSV(collectionName, schemaName, outputPath string) error {
+	return exportTo(outputPath, func(w io.Writer) error {
+		return WriteCollectionCSV(collectionName, schemaName, w)
+	})
+}
+
+// exportTo runs write against outputPath, or stdout when it is empty. A
+// failed export does not leave a partial file behind.
+func exportTo(outputPath string, write func(io.Writer) error) error {
+	if outputPath == "" {
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(outputPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[
+		{"title":"a","n":1.5,"done":true,"tags":["x","y"],"owner":{"name":"ann"},"note":null},
+		{"title":"b","n":-2,"done":false,"tags":[],"owner":{},"note":"héllo \"quoted\""}
+	]`)
+	addTestCollection(t, s, "copy", "")
+
+	path := filepath.Join(t.TempDir(), "notes.json")
+	if err := ExportCollection("notes", "public", path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "[\n  {") {
+		t.Errorf("export is not pretty-printed: %.40q", data)
+	}
+
+	n, err := ImportCollection("copy", "public", path)
+	if err != nil || n != 2 {
+		t.Fatalf("import = %d, %v; want 2 records", n, err)
+	}
+	original, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ReadCollection("copy", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != len(original) {
+		t.Fatalf("imported %d records, want %d", len(imported), len(original))
+	}
+	for i := range original {
+		if got, want := userFields(imported[i]), userFields(original[i]); !reflect.DeepEqual(got, want) {
+			t.Errorf("imported record %v, want %v", got, want)
+		}
+	}
+
+	if err := ExportCollection("missing", "public", filepath.Join(t.TempDir(), "missing.json")); kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+		t.Errorf("export of a missing collection = %v, want %s", err, kerrors.ErrCollectionNotFound)
+	}
+	notArray := filepath.Join(t.TempDir(), "object.json")
+	if err := os.WriteFile(notArray, []byte(`{"title":"a"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportCollection("copy", "public", notArray); kerrors.Code(err) != kerrors.ErrInvalidJSON {
+		t.Errorf("import of an object = %v, want %s", err, kerrors.ErrInvalidJSON)
+	}
+}
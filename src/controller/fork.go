@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+func forkBasePath(branchCollection, schemaName string) string {
+	return filepath.Join(schemaDir(schemaName), branchCollection+".fork-base")
+}
+
+// ForkCollection copies src to <src>-<branchName> and keeps the fork point so
+// the branch can later be merged back with ForkMerge.
+func ForkCollection(src, branchName, schemaName string) error {
+	if branchName == "" {
+		return kerrors.New(kerrors.ErrInvalidRequest, "branch name is required")
+	}
+	branch := src + "-" + branchName
+
+	records, _, err := readRecords(src, schemaName)
+	if err != nil {
+		return err
+	}
+	if err := createCollection(branch, schemaName, records); err != nil {
+		return err
+	}
+
+	// Keep the fork point, encrypted with the branch key, for three-way merges.
+	base, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON data: %v", err)
+	}
+	key, err := readCollectionKey(branch, schemaName)
+	if err != nil {
+		return err
+	}
+	encrypted, err := helper.Encrypt(base, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt data: %v", err)
+	}
+	if err := writeFile(forkBasePath(branch, schemaName), []byte(encrypted)); err != nil {
+		return fmt.Errorf("failed to write fork base: %v", err)
+	}
+
+	fork := types.ForkInfo{
+		ForkedFrom: src,
+		ForkBranch: branchName,
+		ForkedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := UpdateMeta(branch, schemaName, func(meta *types.CollectionMeta) {
+		meta.ForkedFrom = fork.ForkedFrom
+		meta.ForkBranch = fork.ForkBranch
+		meta.ForkedAt = fork.ForkedAt
+	}); err != nil {
+		return err
+	}
+	if err := UpdateMeta(src, schemaName, func(meta *types.CollectionMeta) {
+		meta.Forks = append(meta.Forks, fork)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Forked collection %s to %s\n", src, branch)
+	return nil
+}
+
+// replaceWithBranch gives target the branch's records and drops the branch.
+// An existing target keeps its key, metadata and history, and its data file
+// is replaced in one atomic write; a missing one is the branch renamed. The
+// caller holds both collection locks.
+func replaceWithBranch(branch, target, schemaName string) error {
+	if !collectionExists(target, schemaName) {
+		os.Remove(forkBasePath(branch, schemaName))
+		if err := renameCollectionFiles(branch, target, schemaName); err != nil {
+			return err
+		}
+		return UpdateMeta(target, schemaName, func(m *types.CollectionMeta) {
+			m.ForkedFrom, m.ForkBranch, m.ForkedAt = "", "", ""
+		})
+	}
+
+	if err := checkWritable(target, schemaName); err != nil {
+		return err
+	}
+	records, _, err := readRecords(branch, schemaName)
+	if err != nil {
+		return err
+	}
+	_, key, err := readRecords(target, schemaName)
+	if err != nil {
+		return err
+	}
+	if err := writeRecords(target, schemaName, "update", records, key); err != nil {
+		return err
+	}
+	return dropCollectionLocked(branch, schemaName)
+}
+
+func readForkBase(branch, schemaName string) ([]types.Record, error) {
+	encrypted, err := os.ReadFile(forkBasePath(branch, schemaName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fork base for %s: %v", branch, err)
+	}
+	key, err := readCollectionKey(branch, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := helper.Decrypt(string(encrypted), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt fork base: %v", err)
+	}
+	var records []types.Record
+	if err := json.Unmarshal(decrypted, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse fork base: %v", err)
+	}
+	return records, nil
+}
+
+// ForkMerge merges a branch created by ForkCollection into target.
+//
+// "replace" gives target the branch's records and drops the branch. "merge"
+// replays the branch's changes since the fork point onto target: records the
+// branch added or modified are written to target and records the branch
+// deleted are removed from it.
+func ForkMerge(branch, target, schemaName, strategy string) error {
+	meta, err := ReadMeta(branch, schemaName)
+	if err != nil {
+		return err
+	}
+	if meta.ForkedFrom == "" {
+		return kerrors.New(kerrors.ErrInvalidRequest, "collection %s is not a fork", branch)
+	}
+
+	switch strategy {
+	case "replace":
+		unlock, err := lockCollection(branch, schemaName)
+		if err != nil {
+			return err
+		}
+		unlockTarget, err := lockCollection(target, schemaName)
+		if err != nil {
+			unlock()
+			return err
+		}
+		err = replaceWithBranch(branch, target, schemaName)
+		unlockTarget()
+		unlock()
+		if err != nil {
+			return err
+		}
+		removeLockFiles(branch, schemaName)
+	case "merge":
+		base, err := readForkBase(branch, schemaName)
+		if err != nil {
+			return err
+		}
+		tip, _, err := readRecords(branch, schemaName)
+		if err != nil {
+			return err
+		}
+		unlock, err := lockCollection(target, schemaName)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		if err := checkWritable(target, schemaName); err != nil {
+			return err
+		}
+		records, key, err := readRecords(target, schemaName)
+		if err != nil {
+			return err
+		}
+
+		baseByID := make(map[interface{}]types.Record, len(base))
+		for _, record := range base {
+			baseByID[record["_id"]] = record
+		}
+		tipByID := make(map[interface{}]types.Record, len(tip))
+		for _, record := range tip {
+			tipByID[record["_id"]] = record
+		}
+
+		merged := make([]types.Record, 0, len(records))
+		present := make(map[interface{}]bool, len(records))
+		for _, record := range records {
+			id := record["_id"]
+			_, inBase := baseByID[id]
+			branchRecord, inTip := tipByID[id]
+			switch {
+			case inBase && !inTip:
+				continue
+			case inTip && !reflect.DeepEqual(branchRecord, baseByID[id]):
+				record = branchRecord
+			}
+			merged = append(merged, record)
+			present[id] = true
+		}
+		for _, record := range tip {
+			if _, inBase := baseByID[record["_id"]]; !inBase && !present[record["_id"]] {
+				merged = append(merged, record)
+			}
+		}
+
+		if err := writeRecords(target, schemaName, "update", merged, key); err != nil {
+			return err
+		}
+	default:
+		return kerrors.New(kerrors.ErrInvalidRequest, "unknown merge strategy %q (expected replace or merge)", strategy)
+	}
+
+	fmt.Printf("Merged %s into %s using %s strategy\n", branch, target, strategy)
+	return nil
+}
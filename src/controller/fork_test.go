@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestForkMergeReplaceKeepsTargetMeta(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+	if err := SetACL("notes", "public", "apikey:ops", []string{PermRead, PermWrite}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ForkCollection("notes", "draft", "public"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertRecord(context.Background(), "notes-draft", `{"title":"b"}`, "public"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ForkMerge("notes-draft", "notes", "public", "replace"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("target has %d records after replace, want 2", len(records))
+	}
+	meta, err := ReadMeta("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.ACL) != 1 || meta.ACL[0].Identity != "apikey:ops" {
+		t.Errorf("target ACL after replace = %+v", meta.ACL)
+	}
+	if collectionExists("notes-draft", "public") {
+		t.Error("branch still exists after replace")
+	}
+}
+
+func TestForkMergeReplaceRespectsReadOnly(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+	if err := ForkCollection("notes", "draft", "public"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetReadOnly("notes", "public", true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ForkMerge("notes-draft", "notes", "public", "replace")
+	if kerrors.Code(err) != kerrors.ErrReadOnly {
+		t.Fatalf("replace into a read-only target = %v, want %s", err, kerrors.ErrReadOnly)
+	}
+	if !collectionExists("notes-draft", "public") {
+		t.Error("branch dropped although the merge was refused")
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"fmt"
+
+	kerrors "kite/src/errors"
+	"kite/src/history"
+	"kite/src/types"
+)
+
+func historyLimit() int {
+	if n := currentConfig().HistorySnapshots; n != nil {
+		return *n
+	}
+	return history.DefaultSnapshots
+}
+
+// CollectionHistory lists the stored snapshots of a collection, newest first.
+func CollectionHistory(collectionName, schemaName string) ([]types.Snapshot, error) {
+	if !collectionExists(collectionName, schemaName) {
+		return nil, kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", collectionName, schemaDir(schemaName))
+	}
+	return history.ListSnapshots(schemaDir(schemaName), collectionName)
+}
+
+// SnapshotRecords returns the records of a collection as they were at
+// timestamp.
+func SnapshotRecords(collectionName, schemaName, timestamp string) ([]types.Record, error) {
+	data, err := history.LoadSnapshot(schemaDir(schemaName), collectionName, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	records, _, err := decodeRecords(dataDir(collectionName, schemaName), collectionName, schemaName, data)
+	return records, err
+}
+
+// RestoreSnapshot replaces a collection with its contents at timestamp. The
+// restore is itself a write, so it can be undone and shows up in history.
+func RestoreSnapshot(collectionName, schemaName, timestamp string) error {
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := history.LoadSnapshot(schemaDir(schemaName), collectionName, timestamp)
+	if err != nil {
+		return err
+	}
+	records, _, err := decodeRecords(dataDir(collectionName, schemaName), collectionName, schemaName, data)
+	if err != nil {
+		return err
+	}
+	if err := saveCollection(collectionName, schemaName, "restore", len(records), data); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored collection %s to snapshot %s\n", collectionName, timestamp)
+	return nil
+}
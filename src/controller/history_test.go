@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	kerrors "kite/src/errors"
+	"kite/src/history"
+	"kite/src/types"
+)
+
+func TestCollectionHistory(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	addTestCollection(t, s, "notes", `[{"n":0}]`)
+	for n := 1; n <= 3; n++ {
+		if err := s.InsertRecord(ctx, "notes", fmt.Sprintf(`{"n":%d}`, n), "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err := CollectionHistory("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 4 {
+		t.Fatalf("history has %d snapshots, want the create and 3 inserts: %+v", len(snapshots), snapshots)
+	}
+	files, err := os.ReadDir(history.Dir(schemaDir("public"), "notes"))
+	if err != nil || len(files) != 4 {
+		t.Errorf("history directory holds %d files (%v), want 4", len(files), err)
+	}
+	wantSnapshots := []types.Snapshot{
+		{Operation: "insert", RecordCount: 4},
+		{Operation: "insert", RecordCount: 3},
+		{Operation: "insert", RecordCount: 2},
+		{Operation: "create", RecordCount: 1},
+	}
+	for i, want := range wantSnapshots {
+		if snapshots[i].Operation != want.Operation || snapshots[i].RecordCount != want.RecordCount || snapshots[i].SizeBytes == 0 {
+			t.Errorf("snapshot %d = %+v, want %s of %d records", i, snapshots[i], want.Operation, want.RecordCount)
+		}
+	}
+
+	records, err := SnapshotRecords("notes", "public", snapshots[2].Timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1]["n"] != 1.0 {
+		t.Errorf("records at the first insert = %v", records)
+	}
+
+	if _, err := SnapshotRecords("notes", "public", "1"); kerrors.Code(err) != kerrors.ErrSnapshotNotFound {
+		t.Errorf("unknown snapshot = %v, want %s", err, kerrors.ErrSnapshotNotFound)
+	}
+	if _, err := SnapshotRecords("notes", "public", "../notes"); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+		t.Errorf("malformed timestamp = %v, want %s", err, kerrors.ErrInvalidRequest)
+	}
+	if _, err := CollectionHistory("missing", "public"); kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+		t.Errorf("history of a missing collection = %v, want %s", err, kerrors.ErrCollectionNotFound)
+	}
+}
+
+func TestHistoryKeepsConfiguredSnapshots(t *testing.T) {
+	keep := 2
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64, HistorySnapshots: &keep}
+	Configure(cfg)
+	s := NewStore(cfg)
+	if err := EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	addTestCollection(t, s, "notes", `[{"n":0}]`)
+	for n := 1; n <= 3; n++ {
+		if err := s.InsertRecord(context.Background(), "notes", fmt.Sprintf(`{"n":%d}`, n), "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshots, err := CollectionHistory("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[0].RecordCount != 4 || snapshots[1].RecordCount != 3 {
+		t.Errorf("history = %+v, want the 2 newest snapshots", snapshots)
+	}
+}
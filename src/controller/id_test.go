@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestMalformedIDsAreRejected(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+	records, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := records[0]["_id"].(string)
+
+	ops := map[string]func(recordID string) error{
+		"edit": func(recordID string) error {
+			_, err := s.EditCollection(context.Background(), "notes", recordID, `{"title":"z"}`, "public", "")
+			return err
+		},
+		"move": func(recordID string) error {
+			return s.MoveRecord(context.Background(), "notes", recordID, "public", "")
+		},
+	}
+	for name, op := range ops {
+		if err := op("not-an-id"); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+			t.Errorf("%s of a malformed id = %v, want %s", name, err, kerrors.ErrInvalidRequest)
+		}
+		if err := op(""); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+			t.Errorf("%s of an empty id = %v, want %s", name, err, kerrors.ErrInvalidRequest)
+		}
+	}
+
+	if _, err := s.EditCollection(context.Background(), "notes", id, `{"title":"z"}`, "public", ""); err != nil {
+		t.Fatalf("edit of a valid id = %v", err)
+	}
+	if err := s.MoveRecord(context.Background(), "notes", id, "public", ""); err != nil {
+		t.Fatalf("move of a valid id = %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	kerrors "kite/src/errors"
+)
+
+// ParseNDJSON reads one JSON object per line, ignoring blank lines.
+func ParseNDJSON(r io.Reader) ([]map[string]interface{}, error) {
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON on line %d: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %v", err)
+	}
+	return records, nil
+}
+
+// csvValue converts a CSV cell to a number or bool when it looks like one.
+func csvValue(cell string) interface{} {
+	switch cell {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	return cell
+}
+
+// ParseCSV reads a CSV file using the header row as field names. Numeric and
+// boolean cells are converted, empty cells are left out, and the reserved
+// metadata columns are dropped. Rows whose column count does not match the
+// header are skipped with a warning.
+func ParseCSV(r io.Reader) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	records := make([]map[string]interface{}, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if len(row) != len(header) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping CSV row %d: expected %d columns, got %d\n", i+2, len(header), len(row))
+			continue
+		}
+		record := make(map[string]interface{}, len(header))
+		for j, field := range header {
+			if isReservedField(field) || row[j] == "" {
+				continue
+			}
+			record[field] = csvValue(row[j])
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ImportCSV appends the rows of a CSV file to a collection and returns how
+// many were imported.
+func ImportCSV(collectionName, schemaName, inputPath string) (int, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read input file: %v", err)
+	}
+	records, err := ParseCSV(file)
+	file.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	inserted, _, errs, err := ImportWithDedup(collectionName, schemaName, "", records)
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", e)
+	}
+	return inserted, err
+}
+
+// ImportCollection appends the objects of a JSON array file, such as one
+// written by ExportCollection, to a collection and returns how many were
+// imported. Stored metadata in the file is replaced; elements that are not
+// objects are skipped with a warning.
+func ImportCollection(collectionName, schemaName, inputPath string) (int, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read input file: %v", err)
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		if json.Valid(data) {
+			return 0, kerrors.New(kerrors.ErrInvalidJSON, "%s must contain a JSON array of records", inputPath)
+		}
+		return 0, kerrors.New(kerrors.ErrInvalidJSON, "failed to parse %s: %v", inputPath, err)
+	}
+
+	records := make([]map[string]interface{}, 0, len(elements))
+	for i, raw := range elements {
+		var record map[string]interface{}
+		if err := unmarshalJSON(raw, &record); err != nil || record == nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping record %d: not a JSON object\n", i)
+			continue
+		}
+		// newRecord drops the other reserved fields; _id would be kept.
+		delete(record, "_id")
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		fmt.Printf("Imported 0 records into %s\n", collectionName)
+		return 0, nil
+	}
+
+	inserted, _, errs, err := ImportWithDedup(collectionName, schemaName, "", records)
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", e)
+	}
+	return inserted, err
+}
+
+func dedupKey(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ImportWithDedup appends records to a collection, skipping any whose
+// dedupField value already exists in the collection or earlier in the batch.
+// An empty dedupField imports everything.
+func ImportWithDedup(collectionName, schemaName, dedupField string, records []map[string]interface{}) (inserted, skipped int, errs []error, err error) {
+	return ImportWithProgress(collectionName, schemaName, dedupField, records, nil)
+}
+
+// ImportWithProgress is ImportWithDedup with a callback that is told how many
+// records have been processed and inserted so far.
+func ImportWithProgress(collectionName, schemaName, dedupField string, records []map[string]interface{}, progress func(processed, inserted int)) (inserted, skipped int, errs []error, err error) {
+	if progress == nil {
+		progress = func(int, int) {}
+	}
+	if !collectionExists(collectionName, schemaName) {
+		if err := addCollection(collectionName, schemaName, ""); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer unlock()
+
+	existing, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	eventSourced, err := isEventSourced(collectionName, schemaName)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	ids := make(map[interface{}]bool, len(existing))
+	for _, record := range existing {
+		ids[record["_id"]] = true
+	}
+
+	seen := make(map[string]bool)
+	if dedupField != "" {
+		for _, record := range existing {
+			if v, ok := record[dedupField]; ok {
+				if k, err := dedupKey(v); err == nil {
+					seen[k] = true
+				}
+			}
+		}
+	}
+
+	for i, inputData := range records {
+		if i%1000 == 0 {
+			progress(i, inserted)
+		}
+		if inputData == nil {
+			errs = append(errs, fmt.Errorf("record %d: not a JSON object", i))
+			continue
+		}
+		if dedupField != "" {
+			if v, ok := inputData[dedupField]; ok {
+				k, err := dedupKey(v)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("record %d: %v", i, err))
+					continue
+				}
+				if seen[k] {
+					skipped++
+					continue
+				}
+				seen[k] = true
+			}
+		}
+		record := newRecord(inputData)
+		// Keep identifiers carried over from another database, such as a
+		// MongoDB ObjectId, unless that _id is already taken.
+		if id, ok := inputData["_id"].(string); ok && id != "" && !eventSourced {
+			if ids[id] {
+				skipped++
+				continue
+			}
+			ids[id] = true
+			record["_id"] = id
+		}
+		if eventSourced {
+			if record, err = newEvent(existing, inputData); err != nil {
+				errs = append(errs, fmt.Errorf("record %d: %v", i, err))
+				continue
+			}
+		}
+		existing = append(existing, record)
+		inserted++
+	}
+
+	if inserted > 0 {
+		if err := writeRecords(collectionName, schemaName, "insert", existing, key); err != nil {
+			return 0, 0, errs, err
+		}
+	}
+	progress(len(records), inserted)
+
+	fmt.Printf("Imported %d records into %s (%d duplicates skipped)\n", inserted, collectionName, skipped)
+	return inserted, skipped, errs, nil
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestImportWithDedup(t *testing.T) {
+	s := newTestStore(t)
+	records := make([]map[string]interface{}, 100)
+	for i := range records {
+		// The last 20 repeat the emails of the first 20.
+		records[i] = map[string]interface{}{"email": fmt.Sprintf("user%d@example.com", i%80), "n": i}
+	}
+
+	inserted, skipped, errs, err := s.ImportWithDedup("users", "public", "email", records)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("import: %v, %v", err, errs)
+	}
+	if inserted != 80 || skipped != 20 {
+		t.Errorf("inserted, skipped = %d, %d, want 80, 20", inserted, skipped)
+	}
+	stored, err := ReadCollection("users", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 80 {
+		t.Errorf("collection holds %d records, want 80", len(stored))
+	}
+
+	// Records already in the collection count as duplicates too.
+	inserted, skipped, _, err = s.ImportWithDedup("users", "public", "email", records[:10])
+	if err != nil || inserted != 0 || skipped != 10 {
+		t.Errorf("reimport = %d inserted, %d skipped, %v; want 0, 10", inserted, skipped, err)
+	}
+}
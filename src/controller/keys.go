@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+)
+
+const (
+	KeyDerivationRandom = "random"
+	KeyDerivationArgon2 = "argon2"
+)
+
+// derivedKeyHeader starts the data file of a collection whose key is
+// derived from the master password instead of stored in a .key file.
+const derivedKeyHeader = "kite:argon2:"
+
+type derivedKey struct {
+	password string
+	key      []byte
+}
+
+// Argon2 is deliberately slow, so derived keys are kept for the life of
+// the process.
+var (
+	derivedKeysMu sync.Mutex
+	derivedKeys   = make(map[string]derivedKey)
+)
+
+func masterPassword() string {
+	if pw := currentConfig().MasterPassword; pw != "" {
+		return pw
+	}
+	return os.Getenv("KITE_MASTER_PASSWORD")
+}
+
+// deriveCollectionKey derives a collection's key from the master password,
+// salted with an HMAC of the collection and schema names.
+func deriveCollectionKey(collectionName, schemaName string) ([]byte, error) {
+	password := masterPassword()
+	if password == "" {
+		return nil, kerrors.New(kerrors.ErrInvalidRequest, "collection %s uses a derived key but no master password is configured", collectionName)
+	}
+
+	id := schemaName + "\x00" + collectionName
+	derivedKeysMu.Lock()
+	defer derivedKeysMu.Unlock()
+	if cached, ok := derivedKeys[id]; ok && cached.password == password {
+		return cached.key, nil
+	}
+
+	mac := hmac.New(sha256.New, []byte(password))
+	mac.Write([]byte(id))
+	key, err := helper.DeriveKey([]byte(password), mac.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	derivedKeys[id] = derivedKey{password: password, key: key}
+	return key, nil
+}
+
+// newCollectionKey returns the key for a collection about to be created
+// and whether it is derived rather than stored.
+func newCollectionKey(collectionName, schemaName string) ([]byte, bool, error) {
+	if currentConfig().KeyDerivation == KeyDerivationArgon2 {
+		key, err := deriveCollectionKey(collectionName, schemaName)
+		return key, true, err
+	}
+	key, err := helper.GenerateKey()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate key: %v", err)
+	}
+	return key, false, nil
+}
+
+// usesDerivedKey reports whether a collection's data file carries the
+// derived key header. A collection that does not exist yet follows the
+// key_derivation setting.
+func usesDerivedKey(collectionName, schemaName string) bool {
+	f, err := os.Open(filepath.Join(dataDir(collectionName, schemaName), collectionName+".txt"))
+	if err != nil {
+		return currentConfig().KeyDerivation == KeyDerivationArgon2
+	}
+	defer f.Close()
+	header := make([]byte, len(derivedKeyHeader))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	return string(header) == derivedKeyHeader
+}
+
+// readCollectionKey returns the key of an existing collection.
+func readCollectionKey(collectionName, schemaName string) ([]byte, error) {
+	if usesDerivedKey(collectionName, schemaName) {
+		return deriveCollectionKey(collectionName, schemaName)
+	}
+	key, err := os.ReadFile(filepath.Join(dataDir(collectionName, schemaName), collectionName+".key"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %v", err)
+	}
+	return key, nil
+}
+
+// decrypt decrypts collection files in decryptCollectionData. Tests replace
+// it to count decryptions.
+var decrypt = helper.Decrypt
+
+// decryptCollectionData decrypts the contents of a collection's data file
+// found in dir and returns the plaintext and the key it was encrypted with.
+func decryptCollectionData(dir, collectionName, schemaName string, data []byte) ([]byte, []byte, error) {
+	var key []byte
+	if rest, ok := bytes.CutPrefix(data, []byte(derivedKeyHeader)); ok {
+		derived, err := deriveCollectionKey(collectionName, schemaName)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, data = derived, rest
+	} else {
+		stored, err := os.ReadFile(filepath.Join(dir, collectionName+".key"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read key file: %v", err)
+		}
+		key = stored
+	}
+
+	decrypted, err := decrypt(string(data), key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt data: %v", err)
+	}
+	return decrypted, key, nil
+}
+
+// encryptCollectionData encrypts plaintext for a collection's data file,
+// adding the derived key header when the collection uses one.
+func encryptCollectionData(collectionName, schemaName string, plaintext, key []byte) ([]byte, error) {
+	encrypted, err := helper.Encrypt(plaintext, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %v", err)
+	}
+	if usesDerivedKey(collectionName, schemaName) {
+		return []byte(derivedKeyHeader + encrypted), nil
+	}
+	return []byte(encrypted), nil
+}
+
+// DecryptCollectionData decrypts the contents of a collection's data file
+// found in dir.
+func DecryptCollectionData(dir, collectionName, schemaName string, data []byte) ([]byte, error) {
+	decrypted, _, err := decryptCollectionData(dir, collectionName, schemaName, data)
+	return decrypted, err
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"bytes"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestDerivedKeysSeparateSchemaAndCollection(t *testing.T) {
+	Configure(types.DBConfig{DBPath: t.TempDir(), MasterPassword: "secret", KeyDerivation: KeyDerivationArgon2})
+
+	// "ab" in schema "c" and "a" in schema "bc" join to the same string
+	// without a separator.
+	a, err := deriveCollectionKey("ab", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := deriveCollectionKey("a", "bc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("different schema and collection pairs derived the same key")
+	}
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func ListCollections(schemaName string) ([]string, error) {
+	entries, err := os.ReadDir(schemaDir(schemaName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema directory: %v", err)
+	}
+
+	var collections []string
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+		case filepath.Ext(name) == ".txt":
+			collections = append(collections, strings.TrimSuffix(name, ".txt"))
+		case strings.HasSuffix(name, ".schema.json"):
+			// Collections stored at a custom path only leave their schema
+			// file here.
+			collectionName := strings.TrimSuffix(name, ".schema.json")
+			if dir := dataDir(collectionName, schemaName); dir != schemaDir(schemaName) && collectionExists(collectionName, schemaName) {
+				collections = append(collections, collectionName)
+			}
+		}
+	}
+	return collections, nil
+}
+
+// SystemSchema holds server state, such as token keys, rather than
+// collections.
+const SystemSchema = "_system"
+
+// ListSchemas returns the schema directories under the database root.
+func ListSchemas() ([]string, error) {
+	entries, err := os.ReadDir(schemaDir(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database directory: %v", err)
+	}
+
+	var schemas []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && entry.Name() != SystemSchema {
+			schemas = append(schemas, entry.Name())
+		}
+	}
+	return schemas, nil
+}
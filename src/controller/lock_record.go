@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+const defaultLockTTL = 30 * time.Second
+
+// checkLock returns an error if record holds an unexpired lock owned by
+// someone other than identity.
+func checkLock(record types.Record, identity string) error {
+	owner, _ := record["_locked_by"].(string)
+	if owner == "" || owner == identity {
+		return nil
+	}
+	expires, _ := record["_lock_expires_at"].(string)
+	expiresAt, err := time.Parse(time.RFC3339, expires)
+	if err != nil || !time.Now().Before(expiresAt) {
+		return nil
+	}
+	return kerrors.New(kerrors.ErrRecordLocked, "record %s is locked by %s until %s", record["_id"], owner, expires)
+}
+
+// LockRecord marks a record as locked by identity for ttl (default 30s).
+// The owner may renew the lock at any time.
+func LockRecord(collectionName, id, schemaName, identity, ttl string) error {
+	duration := defaultLockTTL
+	if ttl != "" {
+		var err error
+		duration, err = time.ParseDuration(ttl)
+		if err != nil || duration <= 0 {
+			return kerrors.New(kerrors.ErrInvalidRequest, "invalid lock ttl %q", ttl)
+		}
+	}
+	if identity == "" {
+		return kerrors.New(kerrors.ErrInvalidRequest, "an identity is required to lock a record")
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record["_id"] != id {
+			continue
+		}
+		if err := checkLock(record, identity); err != nil {
+			return err
+		}
+		record["_locked_by"] = identity
+		record["_lock_expires_at"] = time.Now().UTC().Add(duration).Format(time.RFC3339)
+		if err := writeRecords(collectionName, schemaName, "update", records, key); err != nil {
+			return err
+		}
+		fmt.Printf("Locked record %s in collection %s for %s\n", id, collectionName, duration)
+		return nil
+	}
+	return kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
+}
+
+// UnlockRecord releases a lock held by identity. Expired locks may be
+// released by anyone.
+func UnlockRecord(collectionName, id, schemaName, identity string) error {
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record["_id"] != id {
+			continue
+		}
+		if err := checkLock(record, identity); err != nil {
+			return err
+		}
+		delete(record, "_locked_by")
+		delete(record, "_lock_expires_at")
+		if err := writeRecords(collectionName, schemaName, "update", records, key); err != nil {
+			return err
+		}
+		fmt.Printf("Unlocked record %s in collection %s\n", id, collectionName)
+		return nil
+	}
+	return kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
+}
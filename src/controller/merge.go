@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+// MergeRecords folds incoming into a copy of existing. Fields present on both
+// sides with different values are resolved by opts.Strategy; when
+// ConflictFields is set only those fields are treated as conflicts and the
+// rest are overwritten. _id and createdAt always come from existing, _version
+// is bumped and updatedAt restamped, whatever the strategy.
+func MergeRecords(existing, incoming types.Record, opts types.MergeOptions) (types.Record, error) {
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = types.MergeStrategyOverwrite
+	}
+	switch strategy {
+	case types.MergeStrategyOverwrite, types.MergeStrategyPreserve, types.MergeStrategyError:
+	default:
+		return nil, kerrors.New(kerrors.ErrInvalidRequest, "unknown merge strategy %q", opts.Strategy)
+	}
+
+	watched := make(map[string]bool, len(opts.ConflictFields))
+	for _, field := range opts.ConflictFields {
+		watched[field] = true
+	}
+
+	merged := make(types.Record, len(existing)+len(incoming))
+	for k, v := range existing {
+		merged[k] = v
+	}
+
+	var conflicts []string
+	for k, v := range incoming {
+		if isReservedField(k) {
+			continue
+		}
+		current, ok := existing[k]
+		if !ok || reflect.DeepEqual(current, v) || (len(watched) > 0 && !watched[k]) {
+			merged[k] = v
+			continue
+		}
+		switch strategy {
+		case types.MergeStrategyOverwrite:
+			merged[k] = v
+		case types.MergeStrategyError:
+			conflicts = append(conflicts, fmt.Sprintf("%s (existing %v, incoming %v)", k, current, v))
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, kerrors.New(kerrors.ErrMergeConflict, "merge conflict on %s", strings.Join(conflicts, ", "))
+	}
+
+	version, _ := helper.ToFloat64(existing["_version"])
+	merged["_version"] = version + 1
+	merged["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+	return merged, nil
+}
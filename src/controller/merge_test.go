@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+func TestMergeRecords(t *testing.T) {
+	existing := types.Record{"_id": "1", "createdAt": "2024-01-01T00:00:00Z", "_version": 2.0, "name": "ann", "city": "Oslo"}
+	incoming := types.Record{"_id": "2", "createdAt": "2025-01-01T00:00:00Z", "_version": 9.0, "name": "anne", "city": "Bergen", "age": 41.0}
+
+	tests := []struct {
+		opts       types.MergeOptions
+		name, city string
+	}{
+		{types.MergeOptions{Strategy: types.MergeStrategyOverwrite}, "anne", "Bergen"},
+		{types.MergeOptions{Strategy: types.MergeStrategyPreserve}, "ann", "Oslo"},
+		{types.MergeOptions{}, "anne", "Bergen"},
+		// Only name is a conflict, so city is overwritten.
+		{types.MergeOptions{Strategy: types.MergeStrategyPreserve, ConflictFields: []string{"name"}}, "ann", "Bergen"},
+	}
+	for _, tt := range tests {
+		merged, err := MergeRecords(existing, incoming, tt.opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		if merged["name"] != tt.name || merged["city"] != tt.city || merged["age"] != 41.0 {
+			t.Errorf("%+v merged to %v, want name %s and city %s", tt.opts, merged, tt.name, tt.city)
+		}
+		if merged["_id"] != "1" || merged["createdAt"] != "2024-01-01T00:00:00Z" || merged["_version"] != 3.0 || merged["updatedAt"] == nil {
+			t.Errorf("%+v metadata = %v, want existing _id and createdAt at version 3", tt.opts, merged)
+		}
+	}
+	if existing["name"] != "ann" {
+		t.Error("MergeRecords modified existing")
+	}
+
+	_, err := MergeRecords(existing, incoming, types.MergeOptions{Strategy: types.MergeStrategyError})
+	if kerrors.Code(err) != kerrors.ErrMergeConflict {
+		t.Fatalf("error strategy = %v, want %s", err, kerrors.ErrMergeConflict)
+	}
+	for _, want := range []string{"city (existing Oslo, incoming Bergen)", "name (existing ann, incoming anne)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("conflict error %q does not mention %s", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "_id") || strings.Contains(err.Error(), "_version") {
+		t.Errorf("conflict error %q reports metadata fields", err)
+	}
+
+	if _, err := MergeRecords(existing, incoming, types.MergeOptions{Strategy: "newest"}); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+		t.Errorf("unknown strategy = %v, want %s", err, kerrors.ErrInvalidRequest)
+	}
+}
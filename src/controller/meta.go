@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+func metaPath(collectionName, schemaName string) string {
+	return filepath.Join(schemaDir(schemaName), collectionName+".meta.json")
+}
+
+// ReadMeta returns the collection metadata, or an empty value if the
+// collection has none yet.
+func ReadMeta(collectionName, schemaName string) (types.CollectionMeta, error) {
+	var meta types.CollectionMeta
+	data, err := os.ReadFile(metaPath(collectionName, schemaName))
+	if os.IsNotExist(err) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, fmt.Errorf("failed to read meta file: %v", err)
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("failed to parse meta file: %v", err)
+	}
+	return meta, nil
+}
+
+// WriteMeta replaces the collection metadata. Changes based on the stored
+// metadata should go through UpdateMeta instead, which holds the meta lock
+// between the read and the write.
+func WriteMeta(collectionName, schemaName string, meta types.CollectionMeta) error {
+	if !collectionExists(collectionName, schemaName) {
+		return kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist", collectionName)
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta file: %v", err)
+	}
+	if err := writeCollectionAtomic(metaPath(collectionName, schemaName), data); err != nil {
+		return fmt.Errorf("failed to write meta file: %v", err)
+	}
+	return nil
+}
+
+// IsReadOnly reports whether the collection has been frozen with
+// SetReadOnly.
+func IsReadOnly(collectionName, schemaName string) (bool, error) {
+	meta, err := ReadMeta(collectionName, schemaName)
+	if err != nil {
+		return false, err
+	}
+	return meta.ReadOnly, nil
+}
+
+func checkWritable(collectionName, schemaName string) error {
+	readOnly, err := IsReadOnly(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		return kerrors.New(kerrors.ErrReadOnly, "collection is in read-only mode")
+	}
+	return nil
+}
+
+// SetReadOnly freezes or unfreezes a collection. With force, an unreadable
+// meta file is replaced rather than reported.
+func SetReadOnly(collectionName, schemaName string, readOnly, force bool) error {
+	unlock, err := lockMeta(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	meta, err := ReadMeta(collectionName, schemaName)
+	if err != nil {
+		if !force {
+			return err
+		}
+		meta = types.CollectionMeta{}
+	}
+	meta.ReadOnly = readOnly
+	return WriteMeta(collectionName, schemaName, meta)
+}
+
+// ListCollectionsByTag returns the collections in schemaName whose metadata
+// carries every tag in tags. No tags matches every collection.
+func ListCollectionsByTag(schemaName string, tags []string) ([]types.CollectionSummary, error) {
+	collections, err := ListCollections(schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []types.CollectionSummary{}
+	for _, collectionName := range collections {
+		meta, err := ReadMeta(collectionName, schemaName)
+		if err != nil {
+			return nil, fmt.Errorf("collection %s: %w", collectionName, err)
+		}
+		if !hasAllTags(meta.Tags, tags) {
+			continue
+		}
+		summary := types.CollectionSummary{Name: collectionName, Schema: schemaName, Tags: meta.Tags}
+		if summary.Tags == nil {
+			summary.Tags = []string{}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(have, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// accessResolution is how stale last_accessed_at may get before a read or
+// write rewrites it, so that busy collections are not rewriting their meta
+// file on every request.
+const accessResolution = time.Minute
+
+// touchAccess records the current time as the collection's last access.
+// Failures are ignored so that bookkeeping never breaks a read or write.
+func touchAccess(collectionName, schemaName string) {
+	now := time.Now().UTC()
+	if meta, err := ReadMeta(collectionName, schemaName); err == nil {
+		if last, err := time.Parse(time.RFC3339, meta.LastAccessedAt); err == nil && now.Sub(last) < accessResolution {
+			return
+		}
+	}
+	UpdateMeta(collectionName, schemaName, func(meta *types.CollectionMeta) {
+		meta.LastAccessedAt = now.Format(time.RFC3339)
+	})
+}
+
+// markCreated stamps a new collection's creation time.
+func markCreated(collectionName, schemaName string) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	UpdateMeta(collectionName, schemaName, func(meta *types.CollectionMeta) {
+		meta.CreatedAt = now
+		meta.LastAccessedAt = now
+	})
+}
+
+// UpdateMeta applies fn to the stored metadata and writes the result back,
+// holding the meta lock so that concurrent updates are not lost.
+func UpdateMeta(collectionName, schemaName string, fn func(meta *types.CollectionMeta)) error {
+	unlock, err := lockMeta(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	meta, err := ReadMeta(collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	fn(&meta)
+	return WriteMeta(collectionName, schemaName, meta)
+}
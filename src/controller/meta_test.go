@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kite/src/types"
+)
+
+func TestUpdateMetaConcurrent(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"_id":"1"}]`)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*writers+1)
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- SetACL("notes", "public", fmt.Sprintf("apikey:k%d", i), []string{PermRead})
+		}(i)
+		go func() {
+			defer wg.Done()
+			errs <- UpdateMeta("notes", "public", func(meta *types.CollectionMeta) {
+				meta.LastAccessedAt = time.Now().UTC().Format(time.RFC3339Nano)
+			})
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs <- SetReadOnly("notes", "public", true, false)
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	meta, err := ReadMeta("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.ACL) != writers {
+		t.Errorf("ACL has %d entries after %d concurrent grants", len(meta.ACL), writers)
+	}
+	if !meta.ReadOnly {
+		t.Error("read_only was lost to a concurrent update")
+	}
+
+	entries, err := os.ReadDir(filepath.Join(DBPath(), "public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temp file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestTouchAccessThrottled(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"_id":"1"}]`)
+
+	recent := time.Now().UTC().Add(-accessResolution / 2).Format(time.RFC3339)
+	if err := UpdateMeta("notes", "public", func(meta *types.CollectionMeta) { meta.LastAccessedAt = recent }); err != nil {
+		t.Fatal(err)
+	}
+	touchAccess("notes", "public")
+	if meta, _ := ReadMeta("notes", "public"); meta.LastAccessedAt != recent {
+		t.Errorf("last_accessed_at rewritten within %s: %s", accessResolution, meta.LastAccessedAt)
+	}
+
+	old := time.Now().UTC().Add(-2 * accessResolution).Format(time.RFC3339)
+	if err := UpdateMeta("notes", "public", func(meta *types.CollectionMeta) { meta.LastAccessedAt = old }); err != nil {
+		t.Fatal(err)
+	}
+	touchAccess("notes", "public")
+	if meta, _ := ReadMeta("notes", "public"); meta.LastAccessedAt == old {
+		t.Error("stale last_accessed_at was not updated")
+	}
+}
+
+func TestListCollectionsByTag(t *testing.T) {
+	s := newTestStore(t)
+	tagged := map[string][]string{
+		"accounts": {"auth", "users"},
+		"sessions": {"auth"},
+		"profiles": {"users", "auth", "pii"},
+		"orders":   {"billing"},
+		"scratch":  nil,
+	}
+	for name, tags := range tagged {
+		addTestCollection(t, s, name, `[{"title":"a"}]`)
+		if err := UpdateMeta(name, "public", func(meta *types.CollectionMeta) { meta.Tags = tags }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{[]string{"auth", "users"}, []string{"accounts", "profiles"}},
+		{nil, []string{"accounts", "orders", "profiles", "scratch", "sessions"}},
+		{[]string{"nope"}, []string{}},
+	}
+	for _, tt := range tests {
+		summaries, err := ListCollectionsByTag("public", tt.tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, summary := range summaries {
+			got = append(got, summary.Name)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("tags %v matched %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+
+	if _, err := ListCollectionsByTag("missing", []string{"auth"}); err == nil {
+		t.Error("listing a missing schema succeeded")
+	}
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"kite/src/metrics"
+)
+
+func TestMetricsLabelOnlyExistingCollections(t *testing.T) {
+	newTestStore(t)
+	metrics.Enable()
+
+	if _, err := ReadCollection("made-up-collection", "public"); err == nil {
+		t.Fatal("reading a missing collection succeeded")
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	if strings.Contains(body, "made-up-collection") {
+		t.Error("metrics carry a label for a collection that does not exist")
+	}
+	if !strings.Contains(body, `kite_operation_errors_total{collection="unknown",operation="read",schema="unknown"}`) {
+		t.Errorf("failed read not counted under unknown:\n%s", body)
+	}
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+const migrationPreviewSize = 5
+
+func validateMigration(migration types.SchemaMigration) error {
+	for from, to := range migration.Rename {
+		if isReservedField(from) || isReservedField(to) {
+			return kerrors.New(kerrors.ErrInvalidRequest, "cannot rename metadata field %s to %s", from, to)
+		}
+		if to == "" {
+			return kerrors.New(kerrors.ErrInvalidRequest, "rename of %s needs a new field name", from)
+		}
+	}
+	for field := range migration.Add {
+		if isReservedField(field) {
+			return kerrors.New(kerrors.ErrInvalidRequest, "cannot add metadata field %s", field)
+		}
+	}
+	for _, field := range migration.Remove {
+		if isReservedField(field) {
+			return kerrors.New(kerrors.ErrInvalidRequest, "cannot remove metadata field %s", field)
+		}
+	}
+	return nil
+}
+
+// applyMigration returns a migrated copy of record and whether anything
+// changed. Renames run first, so a rename onto an existing field replaces
+// it; added fields only fill in missing values; removals run last.
+func applyMigration(record types.Record, migration types.SchemaMigration) (types.Record, bool) {
+	migrated := make(types.Record, len(record))
+	for k, v := range record {
+		migrated[k] = v
+	}
+
+	changed := false
+	for from, to := range migration.Rename {
+		if v, ok := migrated[from]; ok && from != to {
+			migrated[to] = v
+			delete(migrated, from)
+			changed = true
+		}
+	}
+	for field, value := range migration.Add {
+		if _, ok := migrated[field]; !ok {
+			migrated[field] = value
+			changed = true
+		}
+	}
+	for _, field := range migration.Remove {
+		if _, ok := migrated[field]; ok {
+			delete(migrated, field)
+			changed = true
+		}
+	}
+	return migrated, changed
+}
+
+// MigrateSchema renames, adds and removes fields across every record of a
+// collection in one write and returns the number of records changed.
+func MigrateSchema(collectionName, schemaName string, migration types.SchemaMigration) (int, error) {
+	if err := validateMigration(migration); err != nil {
+		return 0, err
+	}
+	if eventSourced, err := isEventSourced(collectionName, schemaName); err != nil {
+		return 0, err
+	} else if eventSourced {
+		return 0, kerrors.New(kerrors.ErrEventSourced, "collection %s is event sourced; records cannot be edited", collectionName)
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	records, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	modified := 0
+	for i, record := range records {
+		migrated, changed := applyMigration(record, migration)
+		if !changed {
+			continue
+		}
+		if err := checkLock(record, ""); err != nil {
+			return 0, err
+		}
+		version, _ := helper.ToFloat64(record["_version"])
+		migrated["_version"] = version + 1
+		migrated["updatedAt"] = now
+		records[i] = migrated
+		modified++
+	}
+	if modified == 0 {
+		return 0, nil
+	}
+
+	if err := writeRecords(collectionName, schemaName, "migrate", records, key); err != nil {
+		return 0, err
+	}
+	return modified, nil
+}
+
+// PreviewMigration shows the first few records before and after migration
+// without writing anything, along with how many records would change.
+func PreviewMigration(collectionName, schemaName string, migration types.SchemaMigration) ([]types.MigrationPreview, int, error) {
+	if err := validateMigration(migration); err != nil {
+		return nil, 0, err
+	}
+	records, err := ReadPrimaryCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	previews := []types.MigrationPreview{}
+	modified := 0
+	for _, record := range records {
+		migrated, changed := applyMigration(record, migration)
+		if changed {
+			modified++
+		}
+		if len(previews) < migrationPreviewSize {
+			previews = append(previews, types.MigrationPreview{Before: record, After: migrated})
+		}
+	}
+	return previews, modified, nil
+}
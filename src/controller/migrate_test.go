@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"testing"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+func TestMigrateSchema(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "people", `[
+		{"fname":"ann","legacy_id":7},
+		{"fname":"bob","status":"inactive"},
+		{"first_name":"cy"}
+	]`)
+	migration := types.SchemaMigration{
+		Rename: map[string]string{"fname": "first_name"},
+		Add:    map[string]interface{}{"status": "active"},
+		Remove: []string{"legacy_id"},
+	}
+
+	previews, would, err := PreviewMigration("people", "public", migration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if would != 3 || len(previews) != 3 || previews[0].Before["fname"] != "ann" || previews[0].After["first_name"] != "ann" {
+		t.Errorf("preview = %d changes, %+v", would, previews)
+	}
+	if records, _ := ReadCollection("people", "public"); records[0]["fname"] != "ann" {
+		t.Fatal("preview wrote the migration")
+	}
+
+	modified, err := s.MigrateSchema("people", "public", migration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified != 3 {
+		t.Errorf("modified %d records, want 3", modified)
+	}
+	records, err := ReadCollection("people", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ name, status string }{{"ann", "active"}, {"bob", "inactive"}, {"cy", "active"}}
+	for i, record := range records {
+		_, hasOld := record["fname"]
+		_, hasLegacy := record["legacy_id"]
+		if record["first_name"] != want[i].name || record["status"] != want[i].status || hasOld || hasLegacy {
+			t.Errorf("record %d = %v, want first_name %s and status %s only", i, record, want[i].name, want[i].status)
+		}
+	}
+
+	// Nothing left to change.
+	if modified, err := s.MigrateSchema("people", "public", migration); err != nil || modified != 0 {
+		t.Errorf("second migration = %d, %v; want 0", modified, err)
+	}
+	bad := types.SchemaMigration{Rename: map[string]string{"_id": "id"}}
+	if _, err := s.MigrateSchema("people", "public", bad); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+		t.Errorf("renaming _id = %v, want %s", err, kerrors.ErrInvalidRequest)
+	}
+}
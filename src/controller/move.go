@@ -1,40 +1,53 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"kite/src/types"
-	"kite/src/helper"
-	"os"
+	kerrors "kite/src/errors"
+	kid "kite/src/id"
 	"path/filepath"
 )
 
-func MoveRecord(collectionName, id, schemaName string) error {
-	dir := filepath.Join("..", "db")
-	if schemaName != "" {
-		dir = filepath.Join("..", "db", schemaName)
+func moveRecord(ctx context.Context, collectionName, id, schemaName, identity string) error {
+	if err := kid.Validate(id); err != nil {
+		return err
+	}
+	dir := dataDir(collectionName, schemaName)
+
+	if eventSourced, err := isEventSourced(collectionName, schemaName); err != nil {
+		return err
+	} else if eventSourced {
+		return kerrors.New(kerrors.ErrEventSourced, "collection %s is event sourced; records cannot be removed", collectionName)
 	}
 
 	collectionPath := filepath.Join(dir, collectionName+".txt")
-	keyPath := filepath.Join(dir, collectionName+".key")
 
-	encryptedData, err := os.ReadFile(collectionPath)
+	unlock, err := lockCollection(collectionName, schemaName)
 	if err != nil {
-		return fmt.Errorf("failed to read collection file: %v", err)
+		return err
 	}
+	defer unlock()
 
-	key, err := os.ReadFile(keyPath)
+	encryptedData, err := readFileContext(ctx, collectionPath)
 	if err != nil {
-		return fmt.Errorf("failed to read key file: %v", err)
+		return collectionReadError(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	decrypted, err := helper.Decrypt(string(encryptedData), key)
+	decrypted, key, err := decryptCollectionData(dir, collectionName, schemaName, encryptedData)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt data: %v", err)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	var records []types.Record
-	if err := json.Unmarshal(decrypted, &records); err != nil {
+	if err := unmarshalJSON(decrypted, &records); err != nil {
 		return fmt.Errorf("failed to parse collection JSON: %v", err)
 	}
 
@@ -44,26 +57,35 @@ func MoveRecord(collectionName, id, schemaName string) error {
 		if record["_id"] != id {
 			newRecords = append(newRecords, record)
 		} else {
+			if err := checkLock(record, identity); err != nil {
+				return err
+			}
 			found = true
 		}
 	}
 
 	if !found {
-		return fmt.Errorf("record with _id %s not found", id)
+		return kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
 	}
 
 	dataToEncrypt, err := json.Marshal(newRecords)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON data: %v", err)
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	encrypted, err := helper.Encrypt(dataToEncrypt, key)
+	encrypted, err := encryptCollectionData(collectionName, schemaName, dataToEncrypt, key)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt data: %v", err)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	if err := os.WriteFile(collectionPath, []byte(encrypted), 0600); err != nil {
-		return fmt.Errorf("failed to write collection file: %v", err)
+	if err := saveCollection(collectionName, schemaName, "delete", len(newRecords), encrypted); err != nil {
+		return err
 	}
 
 	fmt.Printf("Removed record %s from collection %s\n", id, collectionName)
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestUseJSONNumberKeepsLargeIntegers(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64, UseJSONNumber: true}
+	Configure(cfg)
+	s := NewStore(cfg)
+	if err := EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	addTestCollection(t, s, "users", "")
+
+	if err := s.InsertRecord(context.Background(), "users", `{"user_id":9007199254740993}`, "public"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadCollection("users", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0]["user_id"] != json.Number("9007199254740993") {
+		t.Fatalf("records = %v, want user_id 9007199254740993 exactly", records)
+	}
+
+	// Versions decode as json.Number too, and edits still bump them.
+	id := records[0]["_id"].(string)
+	if _, err := s.EditCollection(context.Background(), "users", id, `{"user_id":9007199254740995}`, "public", ""); err != nil {
+		t.Fatal(err)
+	}
+	records, err = ReadCollection("users", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0]["user_id"] != json.Number("9007199254740995") || records[0]["_version"] != json.Number("1") {
+		t.Errorf("edited record = %v, want the new user_id exactly and _version 1", records[0])
+	}
+
+	if err := s.InsertRecord(context.Background(), "users", `{"user_id":9007199254740993} trailing`, "public"); err == nil {
+		t.Error("insert with data after the record succeeded")
+	}
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	kerrors "kite/src/errors"
+	"kite/src/history"
+)
+
+// ChmodCollection applies fileMode to a collection's data, key and sidecar
+// files and its history snapshots, and dirMode to the directories holding
+// them.
+func ChmodCollection(collectionName, schemaName string, fileMode, dirMode os.FileMode) error {
+	dir := schemaDir(schemaName)
+	if !collectionExists(collectionName, schemaName) {
+		return kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", collectionName, dir)
+	}
+
+	if err := os.Chmod(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %v", dir, err)
+	}
+	data := dataDir(collectionName, schemaName)
+	if data != dir {
+		if err := os.Chmod(data, dirMode); err != nil {
+			return fmt.Errorf("failed to set permissions on %s: %v", data, err)
+		}
+	}
+	var paths []string
+	for _, ext := range []string{".txt", ".key"} {
+		paths = append(paths, filepath.Join(data, collectionName+ext))
+	}
+	for _, ext := range sidecarSuffixes {
+		paths = append(paths, filepath.Join(dir, collectionName+ext))
+	}
+	for _, path := range paths {
+		if err := os.Chmod(path, fileMode); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to set permissions on %s: %v", path, err)
+		}
+	}
+
+	historyDir := history.Dir(dir, collectionName)
+	entries, err := os.ReadDir(historyDir)
+	if os.IsNotExist(err) {
+		entries = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read history directory: %v", err)
+	} else {
+		for _, d := range []string{filepath.Dir(historyDir), historyDir} {
+			if err := os.Chmod(d, dirMode); err != nil {
+				return fmt.Errorf("failed to set permissions on %s: %v", d, err)
+			}
+		}
+	}
+	for _, entry := range entries {
+		path := filepath.Join(historyDir, entry.Name())
+		if err := os.Chmod(path, fileMode); err != nil {
+			return fmt.Errorf("failed to set permissions on %s: %v", path, err)
+		}
+	}
+
+	fmt.Printf("Set permissions on collection %s to %04o (files) and %04o (directories)\n", collectionName, fileMode, dirMode)
+	return nil
+}
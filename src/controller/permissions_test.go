@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+func TestConfiguredPermissions(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64, FilePermission: "0640", DirPermission: "0750"}
+	Configure(cfg)
+	s := NewStore(cfg)
+	if err := EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+	if err := s.InsertRecord(context.Background(), "notes", `{"title":"b"}`, "public"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := schemaDir("public")
+	assertMode := func(path string, want os.FileMode) {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s has mode %o, want %o", filepath.Base(path), got, want)
+		}
+	}
+	assertMode(dir, 0750)
+	assertMode(filepath.Join(dir, "notes.txt"), 0640)
+	assertMode(filepath.Join(dir, "notes.key"), 0640)
+
+	if err := s.ChmodCollection("notes", "public", 0600, 0700); err != nil {
+		t.Fatal(err)
+	}
+	assertMode(dir, 0700)
+	assertMode(filepath.Join(dir, "notes.txt"), 0600)
+	assertMode(filepath.Join(dir, "notes.key"), 0600)
+
+	if err := s.ChmodCollection("missing", "public", 0600, 0700); kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+		t.Errorf("chmod of a missing collection = %v, want %s", err, kerrors.ErrCollectionNotFound)
+	}
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"kite/src/helper"
+	"kite/src/types"
+
+	"pgregory.net/rapid"
+)
+
+// titleRunes are the characters of generated field values, including ones
+// that need escaping in JSON.
+var titleRunes = []rune("abcxyz019 _-\"\\é漢")
+
+// payloadGen generates one record payload as a JSON object.
+var payloadGen = rapid.Custom(func(t *rapid.T) map[string]interface{} {
+	return map[string]interface{}{
+		"title": rapid.StringOf(rapid.RuneFrom(titleRunes)).Draw(t, "title"),
+		"count": float64(rapid.IntRange(-1000, 1000).Draw(t, "count")),
+		"done":  rapid.Bool().Draw(t, "done"),
+	}
+})
+
+// propertyStore returns a store over a fresh root and a function naming a
+// new collection for each rapid check.
+func propertyStore(t *testing.T) (*Store, func() string) {
+	s := newTestStore(t)
+	n := 0
+	return s, func() string {
+		n++
+		return fmt.Sprintf("prop%d", n)
+	}
+}
+
+// insertAll inserts payloads one at a time into a new collection.
+func insertAll(t *rapid.T, s *Store, collectionName string, payloads []map[string]interface{}) []types.Record {
+	for _, payload := range payloads {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.InsertRecord(context.Background(), collectionName, string(data), "public"); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	records, err := ReadCollection(collectionName, "public")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return records
+}
+
+func TestPropertyInsertThenRead(t *testing.T) {
+	s, next := propertyStore(t)
+	rapid.Check(t, func(t *rapid.T) {
+		payloads := rapid.SliceOfN(payloadGen, 1, 8).Draw(t, "payloads")
+		records := insertAll(t, s, next(), payloads)
+
+		if len(records) != len(payloads) {
+			t.Fatalf("read %d records after inserting %d", len(records), len(payloads))
+		}
+		ids := make(map[string]bool)
+		for _, record := range records {
+			id, _ := record["_id"].(string)
+			if ids[id] {
+				t.Fatalf("_id %q is not unique", id)
+			}
+			ids[id] = true
+
+			created, err := time.Parse(time.RFC3339, record["createdAt"].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			updated, err := time.Parse(time.RFC3339, record["updatedAt"].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created.After(updated) {
+				t.Fatalf("createdAt %v is after updatedAt %v", created, updated)
+			}
+		}
+	})
+}
+
+func TestPropertyEditBumpsVersion(t *testing.T) {
+	s, next := propertyStore(t)
+	rapid.Check(t, func(t *rapid.T) {
+		collectionName := next()
+		payloads := rapid.SliceOfN(payloadGen, 1, 5).Draw(t, "payloads")
+		records := insertAll(t, s, collectionName, payloads)
+		target := records[rapid.IntRange(0, len(records)-1).Draw(t, "target")]
+		before, _ := helper.ToFloat64(target["_version"])
+
+		data, _ := json.Marshal(payloadGen.Draw(t, "edit"))
+		if _, err := s.EditCollection(context.Background(), collectionName, target["_id"].(string), string(data), "public", ""); err != nil {
+			t.Fatalf("edit: %v", err)
+		}
+		after, err := ReadCollection(collectionName, "public")
+		if err != nil {
+			t.Fatal(err)
+		}
+		edited := after[recordIndex(after, target["_id"].(string))]
+		if version, _ := helper.ToFloat64(edited["_version"]); version != before+1 {
+			t.Fatalf("_version went from %v to %v", before, version)
+		}
+	})
+}
+
+func TestPropertyDeleteRemovesOne(t *testing.T) {
+	s, next := propertyStore(t)
+	rapid.Check(t, func(t *rapid.T) {
+		collectionName := next()
+		payloads := rapid.SliceOfN(payloadGen, 1, 6).Draw(t, "payloads")
+		records := insertAll(t, s, collectionName, payloads)
+		target := records[rapid.IntRange(0, len(records)-1).Draw(t, "target")]
+
+		if err := s.MoveRecord(context.Background(), collectionName, target["_id"].(string), "public", ""); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		after, err := ReadCollection(collectionName, "public")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(after) != len(records)-1 {
+			t.Fatalf("%d records after deleting one of %d", len(after), len(records))
+		}
+		if recordIndex(after, target["_id"].(string)) >= 0 {
+			t.Fatal("deleted record is still there")
+		}
+	})
+}
+
+func TestPropertyBulkInsertMatchesSequential(t *testing.T) {
+	s, next := propertyStore(t)
+	rapid.Check(t, func(t *rapid.T) {
+		payloads := rapid.SliceOfN(payloadGen, 1, 8).Draw(t, "payloads")
+		sequential := insertAll(t, s, next(), payloads)
+
+		raw := make([]json.RawMessage, len(payloads))
+		for i, payload := range payloads {
+			raw[i], _ = json.Marshal(payload)
+		}
+		bulkName := next()
+		if _, _, err := s.BulkInsertRecords(bulkName, "public", raw, false, nil); err != nil {
+			t.Fatalf("bulk insert: %v", err)
+		}
+		bulk, err := ReadCollection(bulkName, "public")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if a, b := payloadsOf(sequential), payloadsOf(bulk); fmt.Sprint(a) != fmt.Sprint(b) {
+			t.Fatalf("sequential inserts gave %v, bulk insert gave %v", a, b)
+		}
+	})
+}
+
+// payloadsOf returns the user fields of records as sorted JSON, so
+// collections can be compared ignoring order and generated metadata.
+func payloadsOf(records []types.Record) []string {
+	out := make([]string, 0, len(records))
+	for _, record := range records {
+		data, _ := json.Marshal(userFields(record))
+		out = append(out, string(data))
+	}
+	sort.Strings(out)
+	return out
+}
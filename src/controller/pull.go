@@ -1,36 +1,35 @@
 package controller
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
-	"kite/src/helper"
+	kerrors "kite/src/errors"
+	kid "kite/src/id"
+	"kite/src/types"
 )
 
-func PullCollection(collectionName, schemaName string) error {
-	dir := filepath.Join("..", "db")
-	if schemaName != "" {
-		dir = filepath.Join("..", "db", schemaName)
-	}
+func pullCollection(ctx context.Context, collectionName, schemaName string) error {
+	dir := dataDir(collectionName, schemaName)
 
 	collectionPath := filepath.Join(dir, collectionName+".txt")
-	keyPath := filepath.Join(dir, collectionName+".key")
 
-	encryptedData, err := os.ReadFile(collectionPath)
+	encryptedData, err := readFileContext(ctx, collectionPath)
 	if err != nil {
-		return fmt.Errorf("failed to read collection file: %v", err)
+		return collectionReadError(err)
 	}
-
-	key, err := os.ReadFile(keyPath)
-	if err != nil {
-		return fmt.Errorf("failed to read key file: %v", err)
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	decrypted, err := helper.Decrypt(string(encryptedData), key)
+	decrypted, _, err := decryptCollectionData(dir, collectionName, schemaName, encryptedData)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt data: %v", err)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	var prettyJSON bytes.Buffer
@@ -38,6 +37,24 @@ func PullCollection(collectionName, schemaName string) error {
 		return fmt.Errorf("failed to format JSON: %v", err)
 	}
 
+	touchAccess(collectionName, schemaName)
 	fmt.Printf("Collection %s contents:\n%s\n", collectionName, prettyJSON.String())
 	return nil
-}
\ No newline at end of file
+}
+
+// GetRecord returns the record with the given _id.
+func GetRecord(collectionName, id, schemaName string) (types.Record, error) {
+	if err := kid.Validate(id); err != nil {
+		return nil, err
+	}
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record["_id"] == id {
+			return record, nil
+		}
+	}
+	return nil, kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestGetRecord(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"},{"title":"b"}]`)
+	addTestCollection(t, s, "empty", "")
+
+	records, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := records[1]["_id"].(string)
+
+	record, err := GetRecord("notes", id, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record["_id"] != id || record["title"] != "b" {
+		t.Errorf("GetRecord = %v, want the record titled b", record)
+	}
+
+	tests := []struct {
+		name, collection, id, code string
+	}{
+		{"unknown id", "notes", "42", kerrors.ErrRecordNotFound},
+		{"empty collection", "empty", id, kerrors.ErrRecordNotFound},
+		{"missing collection", "missing", id, kerrors.ErrCollectionNotFound},
+		{"malformed id", "notes", "not-an-id", kerrors.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		if _, err := GetRecord(tt.collection, tt.id, "public"); kerrors.Code(err) != tt.code {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, tt.code)
+		}
+	}
+}
@@ -1,95 +1,193 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
-	"kite/src/helper"
+	kerrors "kite/src/errors"
 	"kite/src/types"
-
-
-	"github.com/google/uuid"
+	"kite/src/writequeue"
 )
 
+// insertRules are the per-collection settings that decide how a new record
+// is added.
+type insertRules struct {
+	collectionName string
+	eventSourced   bool
+	limit          int
+	policy         string
+}
+
+func insertRulesFor(collectionName, schemaName string) (insertRules, error) {
+	rules := insertRules{collectionName: collectionName}
+	var err error
+	if rules.eventSourced, err = isEventSourced(collectionName, schemaName); err != nil {
+		return rules, err
+	}
+	if rules.limit, rules.policy, err = recordLimit(collectionName, schemaName); err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+// appendRecord builds a record (or event) from inputData and adds it to
+// records, enforcing the collection's record limit.
+func (r insertRules) appendRecord(records []types.Record, inputData map[string]interface{}) ([]types.Record, error) {
+	if r.limit > 0 && r.policy == EvictionReject && len(records)+1 > r.limit {
+		return records, capacityError(r.collectionName, r.limit)
+	}
 
-func InsertRecord(collectionName, jsonData, schemaName string) error {
-	dir := filepath.Join("..", "db")
-	if schemaName != "" {
-		dir = filepath.Join("..", "db", schemaName)
+	var record types.Record
+	if r.eventSourced {
+		var err error
+		if record, err = newEvent(records, inputData); err != nil {
+			return records, err
+		}
+	} else {
+		record = newRecord(inputData)
 	}
 
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("failed to create directory %s: %v", dir, err)
+	if r.limit > 0 && r.policy == EvictionLRU {
+		updated := EvictLRU(records, record, r.limit)
+		if len(updated) > r.limit {
+			return records, capacityError(r.collectionName, r.limit)
+		}
+		return updated, nil
 	}
-	if err := os.Chmod(dir, 0700); err != nil {
-		return fmt.Errorf("failed to set permissions on %s: %v", dir, err)
+	return append(records, record), nil
+}
+
+func insertRecord(ctx context.Context, collectionName, jsonData, schemaName string) error {
+	if err := EnsureSchema(schemaName, ""); err != nil {
+		return err
 	}
+	dir := dataDir(collectionName, schemaName)
 
 	collectionPath := filepath.Join(dir, collectionName+".txt")
-	keyPath := filepath.Join(dir, collectionName+".key")
 
 	if _, err := os.Stat(collectionPath); os.IsNotExist(err) {
-		return AddCollection(collectionName, schemaName, jsonData)
+		return addCollection(collectionName, schemaName, jsonData)
 	}
 
-	encryptedData, err := os.ReadFile(collectionPath)
+	// Trim single quotes for Windows compatibility
+	cleanedJSON := strings.Trim(jsonData, "'\"")
+	var inputData map[string]interface{}
+	if err := unmarshalJSON([]byte(cleanedJSON), &inputData); err != nil {
+		return kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
+	}
+
+	if currentConfig().WriteQueueEnabled {
+		if err := checkWritable(collectionName, schemaName); err != nil {
+			return err
+		}
+		writequeue.Enqueue(types.QueuedWrite{Collection: collectionName, Schema: schemaName, Data: inputData})
+		fmt.Printf("Queued record for collection %s\n", collectionName)
+		return nil
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
 	if err != nil {
-		return fmt.Errorf("failed to read collection file: %v", err)
+		return err
 	}
+	defer unlock()
 
-	key, err := os.ReadFile(keyPath)
+	if err := checkCollectionSize(collectionName, collectionPath); err != nil {
+		return err
+	}
+
+	encryptedData, err := readFileContext(ctx, collectionPath)
 	if err != nil {
-		return fmt.Errorf("failed to read key file: %v", err)
+		return collectionReadError(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	decrypted, err := helper.Decrypt(string(encryptedData), key)
+	decrypted, key, err := decryptCollectionData(dir, collectionName, schemaName, encryptedData)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt data: %v", err)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	var records []types.Record
-	if err := json.Unmarshal(decrypted, &records); err != nil {
+	if err := unmarshalJSON(decrypted, &records); err != nil {
 		return fmt.Errorf("failed to parse collection JSON: %v", err)
 	}
 
-	// Trim single quotes for Windows compatibility
-	cleanedJSON := strings.Trim(jsonData, "'\"")
-	var inputData map[string]interface{}
-	if err := json.Unmarshal([]byte(cleanedJSON), &inputData); err != nil {
-		return fmt.Errorf("failed to parse JSON data: %v", err)
-	}
-
-	now := time.Now().UTC().Format(time.RFC3339)
-	newRecord := types.Record{
-		"_id":       uuid.New().String(),
-		"createdAt": now,
-		"updatedAt": now,
-		"_version":  float64(0),
+	rules, err := insertRulesFor(collectionName, schemaName)
+	if err != nil {
+		return err
 	}
-	for k, v := range inputData {
-		if k != "_id" && k != "createdAt" && k != "updatedAt" && k != "_version" {
-			newRecord[k] = v
-		}
+	if records, err = rules.appendRecord(records, inputData); err != nil {
+		return err
 	}
 
-	records = append(records, newRecord)
 	dataToEncrypt, err := json.Marshal(records)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON data: %v", err)
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	encrypted, err := helper.Encrypt(dataToEncrypt, key)
+	encrypted, err := encryptCollectionData(collectionName, schemaName, dataToEncrypt, key)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt data: %v", err)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	if err := os.WriteFile(collectionPath, []byte(encrypted), 0600); err != nil {
-		return fmt.Errorf("failed to write collection file: %v", err)
+	if err := saveCollection(collectionName, schemaName, "insert", len(records), encrypted); err != nil {
+		return err
 	}
 
 	fmt.Printf("Inserted record into collection %s\n", collectionName)
 	return nil
-}
\ No newline at end of file
+}
+// FlushQueuedWrites inserts a batch of queued records for one collection in
+// a single read-modify-write cycle. It is a writequeue.FlushFunc: records that
+// fail validation are skipped and reported in rejected, and the rest are
+// still written.
+func FlushQueuedWrites(collectionName, schemaName string, writes []types.QueuedWrite) (rejected []error, err error) {
+	if err := checkWritable(collectionName, schemaName); err != nil {
+		return nil, err
+	}
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	records, key, err := readRecords(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := insertRulesFor(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected = make([]error, len(writes))
+	refused := 0
+	for i, write := range writes {
+		next, err := rules.appendRecord(records, write.Data)
+		if err != nil {
+			rejected[i] = err
+			refused++
+			continue
+		}
+		records = next
+	}
+	if refused < len(writes) {
+		if err := writeRecords(collectionName, schemaName, "insert", records, key); err != nil {
+			return nil, err
+		}
+	}
+	return rejected, nil
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+	"kite/src/writequeue"
+)
+
+func TestQueuedInserts(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64, WriteQueueEnabled: true}
+	Configure(cfg)
+	s := NewStore(cfg)
+	if err := EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	addTestCollection(t, s, "events", `[{"n":0}]`)
+	count := func() int {
+		t.Helper()
+		records, err := ReadCollection("events", "public")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(records)
+	}
+
+	// Nothing is written until the queue is flushed, as on shutdown.
+	writequeue.Start(time.Hour, FlushQueuedWrites)
+	for n := 1; n <= 10; n++ {
+		if err := s.InsertRecord(ctx, "events", fmt.Sprintf(`{"n":%d}`, n), "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := count(); got != 1 {
+		t.Fatalf("collection holds %d records before the flush, want 1", got)
+	}
+	writequeue.FlushAll()
+	if got := count(); got != 11 {
+		t.Fatalf("collection holds %d records after FlushAll, want 11", got)
+	}
+
+	writequeue.Start(10*time.Millisecond, FlushQueuedWrites)
+	for n := 11; n <= 110; n++ {
+		if err := s.InsertRecord(ctx, "events", fmt.Sprintf(`{"n":%d}`, n), "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for count() != 111 {
+		if time.Now().After(deadline) {
+			t.Fatalf("collection holds %d records, want 111 after the queue drains", count())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := SetReadOnly("events", "public", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertRecord(ctx, "events", `{"n":111}`, "public"); kerrors.Code(err) != kerrors.ErrReadOnly {
+		t.Errorf("queued insert into a read-only collection = %v, want %s", err, kerrors.ErrReadOnly)
+	}
+}
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/filter"
+	"kite/src/types"
+)
+
+// ParseFilter parses a comma-separated list of filter expressions such as
+// status=active,age>=18.
+func ParseFilter(list string) ([]types.FilterExpression, error) {
+	var exprs []types.FilterExpression
+	for _, part := range strings.Split(list, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		expr, err := filter.ParseFilter(part)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+func matchesFilter(record types.Record, exprs []types.FilterExpression) bool {
+	return filter.Match(record, exprs)
+}
+
+// FilterRecords returns the records whose fields equal every value in
+// filters, comparing numerically when both sides are numbers. A field the
+// record lacks never matches.
+func FilterRecords(records []types.Record, filters map[string]string) []types.Record {
+	exprs := make([]types.FilterExpression, 0, len(filters))
+	for field, value := range filters {
+		exprs = append(exprs, types.FilterExpression{Field: field, Op: "eq", Value: value})
+	}
+	return filterRecords(records, exprs)
+}
+
+// DefaultPageLimit is the page size used when neither the request nor the
+// config sets one.
+const DefaultPageLimit = 100
+
+// Paginate returns page (1-based) of records, limit records per page. A limit
+// of 0 uses the configured default. Pages past the end are empty.
+func Paginate(records []types.Record, page, limit int) (types.Page, error) {
+	if limit == 0 {
+		if limit = currentConfig().DefaultPageLimit; limit == 0 {
+			limit = DefaultPageLimit
+		}
+	}
+	if page < 1 || limit < 1 {
+		return types.Page{}, kerrors.New(kerrors.ErrInvalidRequest, "page and limit must be positive")
+	}
+
+	result := types.Page{
+		Data:       []types.Record{},
+		Page:       page,
+		Limit:      limit,
+		Total:      len(records),
+		TotalPages: (len(records) + limit - 1) / limit,
+	}
+	if start := (page - 1) * limit; start < len(records) {
+		end := start + limit
+		if end > len(records) {
+			end = len(records)
+		}
+		result.Data = records[start:end]
+	}
+	return result, nil
+}
+
+// FindRecords reads a collection and returns the records matching every
+// field=value pair in filters.
+func FindRecords(collectionName, schemaName string, filters map[string]string) ([]types.Record, error) {
+	if !collectionExists(collectionName, schemaName) {
+		return nil, kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", collectionName, schemaDir(schemaName))
+	}
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	matched := FilterRecords(records, filters)
+	if matched == nil {
+		matched = []types.Record{}
+	}
+	return matched, nil
+}
+
+// CountRecords returns the number of records in a collection.
+func CountRecords(collectionName, schemaName string) (int, error) {
+	if !collectionExists(collectionName, schemaName) {
+		return 0, kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", collectionName, schemaDir(schemaName))
+	}
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+func filterRecords(records []types.Record, exprs []types.FilterExpression) []types.Record {
+	if len(exprs) == 0 {
+		return records
+	}
+	var matched []types.Record
+	for _, record := range records {
+		if matchesFilter(record, exprs) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+func sortRecords(records []types.Record, field, order string) {
+	sort.SliceStable(records, func(i, j int) bool {
+		cmp := filter.Compare(records[i][field], records[j][field])
+		if order == "desc" {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+func paginate(records []types.Record, limit, offset int) []types.Record {
+	if offset >= len(records) {
+		return nil
+	}
+	records = records[offset:]
+	if limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	return records
+}
+
+// checkQuery validates query options, defaulting order to asc.
+func checkQuery(order string, limit, offset int) (string, error) {
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		return "", fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+	if limit < 0 || offset < 0 {
+		return "", fmt.Errorf("limit and offset must not be negative")
+	}
+	return order, nil
+}
+
+// QueryRecords reads a collection from the primary and applies filter, sort
+// and pagination in that order.
+func QueryRecords(collectionName, schemaName string, exprs []types.FilterExpression, sortField, order string, limit, offset int) ([]types.Record, error) {
+	order, err := checkQuery(order, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	records, err := ReadPrimaryCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	records = filterRecords(records, exprs)
+	if sortField != "" {
+		sortRecords(records, sortField, order)
+	}
+	return paginate(records, limit, offset), nil
+}
+
+// ChangedSince returns the records whose updatedAt is after since.
+func ChangedSince(collectionName, schemaName string, since time.Time) ([]types.Record, error) {
+	records, err := ReadPrimaryCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	changed := []types.Record{}
+	for _, record := range records {
+		s, _ := record["updatedAt"].(string)
+		if updated, err := time.Parse(time.RFC3339, s); err == nil && updated.After(since) {
+			changed = append(changed, record)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestCountRecords(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "empty", "")
+	addTestCollection(t, s, "notes", `[{"title":"a"},{"title":"b"},{"title":"c"}]`)
+
+	for name, want := range map[string]int{"empty": 0, "notes": 3} {
+		n, err := CountRecords(name, "public")
+		if err != nil || n != want {
+			t.Errorf("CountRecords(%s) = %d, %v; want %d", name, n, err, want)
+		}
+	}
+
+	if _, err := CountRecords("missing", "public"); kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+		t.Errorf("CountRecords(missing) = %v, want %s", err, kerrors.ErrCollectionNotFound)
+	}
+}
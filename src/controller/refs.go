@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"fmt"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+// CheckReferences reports records in collectionName whose refField does not
+// match the _id of any record in refCollection. Records without refField, or
+// with a null value, are not references and are skipped. An empty refSchema
+// means schemaName.
+func CheckReferences(collectionName, schemaName, refField, refCollection, refSchema string) ([]types.RefViolation, error) {
+	if refField == "" || refCollection == "" {
+		return nil, kerrors.New(kerrors.ErrInvalidRequest, "ref field and ref collection are required")
+	}
+	if refSchema == "" {
+		refSchema = schemaName
+	}
+	if err := ValidateNames(refSchema, refCollection); err != nil {
+		return nil, err
+	}
+
+	records, err := ReadCollection(collectionName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := ReadCollection(refCollection, refSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(refs))
+	for _, record := range refs {
+		ids[fmt.Sprint(record["_id"])] = true
+	}
+
+	violations := []types.RefViolation{}
+	for _, record := range records {
+		value, ok := record[refField]
+		if !ok || value == nil {
+			continue
+		}
+		if ref := fmt.Sprint(value); !ids[ref] {
+			violations = append(violations, types.RefViolation{Record: record, RefValue: ref})
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestCheckReferences(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	addTestCollection(t, s, "users", `[{"name":"ann"},{"name":"bob"}]`)
+	users, err := ReadCollection("users", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ann, bob := users[0]["_id"].(string), users[1]["_id"].(string)
+	addTestCollection(t, s, "orders", fmt.Sprintf(`[
+		{"item":"lamp","user_id":%q},
+		{"item":"desk","user_id":%q},
+		{"item":"chair","user_id":%q},
+		{"item":"gift card"},
+		{"item":"sample","user_id":null}
+	]`, ann, bob, bob))
+
+	if violations, err := CheckReferences("orders", "public", "user_id", "users", ""); err != nil || len(violations) != 0 {
+		t.Fatalf("before the delete = %+v, %v; want no violations", violations, err)
+	}
+
+	if err := s.MoveRecord(ctx, "users", bob, "public", ""); err != nil {
+		t.Fatal(err)
+	}
+	violations, err := CheckReferences("orders", "public", "user_id", "users", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 2 {
+		t.Fatalf("violations = %+v, want bob's 2 orders", violations)
+	}
+	for i, item := range []string{"desk", "chair"} {
+		if violations[i].Record["item"] != item || violations[i].RefValue != bob {
+			t.Errorf("violation %d = %+v, want %s referencing %s", i, violations[i], item, bob)
+		}
+	}
+
+	if _, err := CheckReferences("orders", "public", "", "users", ""); kerrors.Code(err) != kerrors.ErrInvalidRequest {
+		t.Errorf("missing ref field = %v, want %s", err, kerrors.ErrInvalidRequest)
+	}
+	if _, err := CheckReferences("orders", "public", "user_id", "accounts", ""); err == nil {
+		t.Error("checking against a missing collection succeeded")
+	}
+}
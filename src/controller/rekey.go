@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	"kite/src/history"
+	"kite/src/undo"
+)
+
+// rekeyCollection re-encrypts a collection with a freshly generated key and
+// returns the SHA-256 checksum of the old key, for the audit log.
+//
+// The new key is written next to the old one first, then the data file is
+// replaced, and only then is the new key renamed into place, so a failure
+// at any step leaves a readable collection. History snapshots and undo
+// entries were encrypted with the old key and are discarded; the fork base
+// of a branch is re-encrypted.
+func rekeyCollection(collectionName, schemaName string) (oldKeySum string, err error) {
+	dir := schemaDir(schemaName)
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return oldKeySum, err
+	}
+	defer unlock()
+	if err := checkWritable(collectionName, schemaName); err != nil {
+		return oldKeySum, err
+	}
+	if usesDerivedKey(collectionName, schemaName) {
+		return oldKeySum, kerrors.New(kerrors.ErrInvalidRequest, "collection %s has a key derived from the master password and cannot be rekeyed", collectionName)
+	}
+
+	data := dataDir(collectionName, schemaName)
+	collectionPath := filepath.Join(data, collectionName+".txt")
+	keyPath := filepath.Join(data, collectionName+".key")
+
+	encrypted, err := os.ReadFile(collectionPath)
+	if err != nil {
+		return oldKeySum, collectionReadError(err)
+	}
+	oldKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return oldKeySum, fmt.Errorf("failed to read key file: %v", err)
+	}
+	checksum := sha256.Sum256(oldKey)
+	oldKeySum = hex.EncodeToString(checksum[:])
+
+	decrypted, err := helper.Decrypt(string(encrypted), oldKey)
+	if err != nil {
+		return oldKeySum, fmt.Errorf("failed to decrypt data: %v", err)
+	}
+	newKey, err := helper.GenerateKey()
+	if err != nil {
+		return oldKeySum, fmt.Errorf("failed to generate key: %v", err)
+	}
+	reencrypted, err := helper.Encrypt(decrypted, newKey)
+	if err != nil {
+		return oldKeySum, fmt.Errorf("failed to encrypt data: %v", err)
+	}
+
+	pendingKeyPath := keyPath + ".new"
+	if err := writeCollectionAtomic(pendingKeyPath, newKey); err != nil {
+		return oldKeySum, fmt.Errorf("failed to write key file: %v", err)
+	}
+	if err := writeCollectionAtomic(collectionPath, []byte(reencrypted)); err != nil {
+		os.Remove(pendingKeyPath)
+		return oldKeySum, fmt.Errorf("failed to write collection file: %v", err)
+	}
+	if err := os.Rename(pendingKeyPath, keyPath); err != nil {
+		if restoreErr := writeCollectionAtomic(collectionPath, encrypted); restoreErr != nil {
+			return oldKeySum, fmt.Errorf("failed to replace key file: %v (the new key is in %s)", err, pendingKeyPath)
+		}
+		os.Remove(pendingKeyPath)
+		return oldKeySum, fmt.Errorf("failed to replace key file: %v", err)
+	}
+	currentCache().Invalidate(collectionPath)
+
+	if base, err := os.ReadFile(forkBasePath(collectionName, schemaName)); err == nil {
+		if err := rekeyFile(forkBasePath(collectionName, schemaName), base, oldKey, newKey); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	undo.Clear(undo.Key(schemaName, collectionName))
+	if err := history.Remove(dir, collectionName); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Printf("Rekeyed collection %s\n", collectionName)
+	return oldKeySum, nil
+}
+
+// rekeyFile re-encrypts an encrypted sidecar file in place.
+func rekeyFile(path string, encrypted, oldKey, newKey []byte) error {
+	decrypted, err := helper.Decrypt(string(encrypted), oldKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %v", path, err)
+	}
+	reencrypted, err := helper.Encrypt(decrypted, newKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %v", path, err)
+	}
+	if err := writeCollectionAtomic(path, []byte(reencrypted)); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+// RepairCollection rewrites a collection whose decrypted JSON is damaged,
+// keeping every record that can still be parsed. Recovered records get their
+// _version bumped and a _repaired_at timestamp. A collection that parses
+// cleanly is left untouched. The file must still decrypt; there is nothing
+// to recover otherwise.
+func RepairCollection(collectionName, schemaName string) (saved, lost int, err error) {
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+
+	dir := dataDir(collectionName, schemaName)
+	encrypted, err := os.ReadFile(filepath.Join(dir, collectionName+".txt"))
+	if err != nil {
+		return 0, 0, collectionReadError(err)
+	}
+	decrypted, key, err := decryptCollectionData(dir, collectionName, schemaName, encrypted)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var records []types.Record
+	if err := json.Unmarshal(decrypted, &records); err == nil {
+		return len(records), 0, nil
+	}
+
+	records, lost = recoverRecords(decrypted)
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, record := range records {
+		version, _ := helper.ToFloat64(record["_version"])
+		record["_version"] = version + 1
+		record["_repaired_at"] = now
+	}
+	if records == nil {
+		records = []types.Record{}
+	}
+	if err := writeRecords(collectionName, schemaName, "repair", records, key); err != nil {
+		return 0, 0, err
+	}
+	fmt.Printf("Repaired collection %s: %d records saved, %d lost\n", collectionName, len(records), lost)
+	return len(records), lost, nil
+}
+
+// recoverRecords decodes records from a damaged JSON array. Records before
+// the damage are read in order; past it, the rest is split on "},{"
+// boundaries and each piece that parses as a record is kept.
+func recoverRecords(data []byte) ([]types.Record, int) {
+	var records []types.Record
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return salvage(data)
+	}
+	for dec.More() {
+		var record types.Record
+		if err := dec.Decode(&record); err != nil {
+			break
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	rest := bytes.TrimLeft(data[dec.InputOffset():], " \t\r\n,")
+	if len(bytes.TrimSpace(bytes.TrimRight(rest, " \t\r\n]"))) == 0 {
+		return records, 0
+	}
+	more, lost := salvage(rest)
+	return append(records, more...), lost
+}
+
+func salvage(data []byte) ([]types.Record, int) {
+	var records []types.Record
+	lost := 0
+	data = bytes.Trim(bytes.TrimSpace(data), "[]")
+	for _, piece := range bytes.Split(data, []byte("},{")) {
+		piece = bytes.TrimSpace(piece)
+		if len(piece) == 0 {
+			continue
+		}
+		if piece[0] != '{' {
+			piece = append([]byte("{"), piece...)
+		}
+		if piece[len(piece)-1] != '}' {
+			piece = append(piece, '}')
+		}
+		var record types.Record
+		if err := json.Unmarshal(piece, &record); err != nil || record["_id"] == nil {
+			lost++
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, lost
+}
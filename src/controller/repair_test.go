@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestRepairCollection(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+
+	// A clean collection is left as it is.
+	saved, lost, err := s.RepairCollection("notes", "public")
+	if err != nil || saved != 1 || lost != 0 {
+		t.Fatalf("repair of a clean collection = %d, %d, %v; want 1, 0, nil", saved, lost, err)
+	}
+
+	writeTestPlaintext(t, "notes", `[{"_id":"1","title":"a","_version":0},{"_id":"2","title":"b","_version":0},`+
+		`{"_id":"3","title":"c","_version":2},{"_id":"4","ti\x00tle":`)
+
+	saved, lost, err = s.RepairCollection("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved != 3 || lost != 1 {
+		t.Errorf("repair = %d saved, %d lost; want 3 and 1", saved, lost)
+	}
+
+	records, err := ReadCollection("notes", "public")
+	if err != nil {
+		t.Fatalf("read after repair: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("read %d records after repair, want 3", len(records))
+	}
+	for i, want := range []float64{1, 1, 3} {
+		if records[i]["_version"] != want || records[i]["_repaired_at"] == nil {
+			t.Errorf("record %v, want _version %v and _repaired_at set", records[i], want)
+		}
+	}
+
+	if _, _, err := s.RepairCollection("missing", "public"); err == nil {
+		t.Error("repair of a missing collection succeeded")
+	}
+}
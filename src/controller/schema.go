@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+func collectionSchemaPath(collectionName, schemaName string) string {
+	return filepath.Join(schemaDir(schemaName), collectionName+".schema.json")
+}
+
+// ReadCollectionSchema returns the collection's schema settings, or the zero
+// value if none have been set.
+func ReadCollectionSchema(collectionName, schemaName string) (types.CollectionSchema, error) {
+	var schema types.CollectionSchema
+	data, err := os.ReadFile(collectionSchemaPath(collectionName, schemaName))
+	if os.IsNotExist(err) {
+		return schema, nil
+	}
+	if err != nil {
+		return schema, fmt.Errorf("failed to read schema file: %v", err)
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return schema, fmt.Errorf("failed to parse schema file: %v", err)
+	}
+	return schema, nil
+}
+
+func WriteCollectionSchema(collectionName, schemaName string, schema types.CollectionSchema) error {
+	if !collectionExists(collectionName, schemaName) {
+		return kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist", collectionName)
+	}
+	if schema.Path != "" {
+		if err := ValidateCollectionPath(schema.Path); err != nil {
+			return err
+		}
+	}
+	return writeCollectionSchema(collectionName, schemaName, schema)
+}
+
+func writeCollectionSchema(collectionName, schemaName string, schema types.CollectionSchema) error {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema file: %v", err)
+	}
+	if err := writeCollectionAtomic(collectionSchemaPath(collectionName, schemaName), data); err != nil {
+		return fmt.Errorf("failed to write schema file: %v", err)
+	}
+	return nil
+}
+
+// IsPublicRead reports whether the collection can be read without
+// credentials.
+func IsPublicRead(collectionName, schemaName string) (bool, error) {
+	schema, err := ReadCollectionSchema(collectionName, schemaName)
+	if err != nil {
+		return false, err
+	}
+	return schema.PublicRead, nil
+}
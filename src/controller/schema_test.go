@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestInsertCreatesMissingSchema(t *testing.T) {
+	s := newTestStore(t)
+
+	if err := s.InsertRecord(context.Background(), "users", `{"name":"bob"}`, "myschema"); err != nil {
+		t.Fatalf("insert into a new schema = %v", err)
+	}
+	if info, err := os.Stat(schemaDir("myschema")); err != nil || !info.IsDir() {
+		t.Fatalf("schema directory was not created: %v", err)
+	}
+	records, err := ReadCollection("users", "myschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0]["name"] != "bob" {
+		t.Errorf("records = %v, want bob", records)
+	}
+}
+
+func TestPullMissingSchema(t *testing.T) {
+	s := newTestStore(t)
+
+	err := s.PullCollection(context.Background(), "users", "nowhere")
+	if kerrors.Code(err) != kerrors.ErrSchemaNotFound || !strings.Contains(err.Error(), "schema nowhere does not exist") {
+		t.Errorf("pull from a missing schema = %v, want %s", err, kerrors.ErrSchemaNotFound)
+	}
+	if _, err := os.Stat(schemaDir("nowhere")); !os.IsNotExist(err) {
+		t.Errorf("pull created the schema directory: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func gzipSize(data []byte) (int64, error) {
+	var w countingWriter
+	zw := gzip.NewWriter(&w)
+	if _, err := zw.Write(data); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	return w.n, nil
+}
+
+// CollectionStats reports a collection's size, record count and timestamps.
+// Gathering them does not count as an access.
+func CollectionStats(collectionName, schemaName string) (types.FullCollectionStats, error) {
+	dir := schemaDir(schemaName)
+	stats := types.FullCollectionStats{Collection: collectionName, Schema: schemaName}
+
+	info, err := os.Stat(filepath.Join(dataDir(collectionName, schemaName), collectionName+".txt"))
+	if os.IsNotExist(err) {
+		return stats, kerrors.New(kerrors.ErrCollectionNotFound, "collection %s does not exist in %s", collectionName, dir)
+	}
+	if err != nil {
+		return stats, fmt.Errorf("failed to stat collection file: %v", err)
+	}
+	stats.SizeBytes = info.Size()
+	stats.LastWrite = info.ModTime().UTC().Format(time.RFC3339)
+
+	encryptedData, err := readLocked(dir, collectionName, schemaName)
+	if err != nil {
+		return stats, collectionReadError(err)
+	}
+	records, key, err := decodeRecords(dir, collectionName, schemaName, encryptedData)
+	if err != nil {
+		return stats, err
+	}
+	stats.Records = len(records)
+	stats.Encryption = fmt.Sprintf("AES-%d-GCM", len(key)*8)
+
+	plain, err := json.Marshal(records)
+	if err != nil {
+		return stats, fmt.Errorf("failed to marshal JSON data: %v", err)
+	}
+	if stats.CompressedBytes, err = gzipSize(plain); err != nil {
+		return stats, fmt.Errorf("failed to compress collection: %v", err)
+	}
+
+	meta, err := ReadMeta(collectionName, schemaName)
+	if err != nil {
+		return stats, err
+	}
+	stats.LastRead = meta.LastAccessedAt
+	stats.CreatedAt = meta.CreatedAt
+	if stats.CreatedAt == "" {
+		// Collections created before creation times were recorded.
+		for _, record := range records {
+			if created, _ := record["createdAt"].(string); created != "" && (stats.CreatedAt == "" || created < stats.CreatedAt) {
+				stats.CreatedAt = created
+			}
+		}
+	}
+	return stats, nil
+}
+
+// AllCollectionStats reports CollectionStats for every collection in a
+// schema.
+func AllCollectionStats(schemaName string) ([]types.FullCollectionStats, error) {
+	collections, err := ListCollections(schemaName)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]types.FullCollectionStats, 0, len(collections))
+	for _, collectionName := range collections {
+		stats, err := CollectionStats(collectionName, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, stats)
+	}
+	return all, nil
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kerrors "kite/src/errors"
+)
+
+func TestCollectionStats(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "notes", `[{"title":"a"},{"title":"b"},{"title":"c"}]`)
+	if _, err := ReadCollection("notes", "public"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := CollectionStats("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(schemaDir("public"), "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := ReadMeta("notes", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Collection != "notes" || stats.Schema != "public" || stats.Records != 3 {
+		t.Errorf("stats = %+v, want 3 records in public/notes", stats)
+	}
+	if stats.SizeBytes != info.Size() || stats.LastWrite != info.ModTime().UTC().Format(time.RFC3339) {
+		t.Errorf("size %d and last write %s, want %d and %s", stats.SizeBytes, stats.LastWrite, info.Size(), info.ModTime().UTC().Format(time.RFC3339))
+	}
+	if stats.CompressedBytes <= 0 {
+		t.Errorf("compressed size = %d", stats.CompressedBytes)
+	}
+	if stats.CreatedAt == "" || stats.CreatedAt != meta.CreatedAt || stats.LastRead == "" || stats.LastRead != meta.LastAccessedAt {
+		t.Errorf("created %q and last read %q, want %q and %q from the metadata", stats.CreatedAt, stats.LastRead, meta.CreatedAt, meta.LastAccessedAt)
+	}
+	if stats.Encryption != "AES-256-GCM" {
+		t.Errorf("encryption = %s, want AES-256-GCM", stats.Encryption)
+	}
+
+	if _, err := CollectionStats("missing", "public"); kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+		t.Errorf("stats of a missing collection = %v, want %s", err, kerrors.ErrCollectionNotFound)
+	}
+}
@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kite/src/metrics"
+	"kite/src/types"
+)
+
+// DefaultDBPath is the database root when db_path is not set.
+var DefaultDBPath = filepath.Join("..", "db")
+
+var (
+	dbPathMu sync.RWMutex
+	dbPath   = DefaultDBPath
+)
+
+// DBPath returns the directory holding the schema directories.
+func DBPath() string {
+	dbPathMu.RLock()
+	defer dbPathMu.RUnlock()
+	return dbPath
+}
+
+// AuditFunc receives an audit entry for each write made through a Store.
+type AuditFunc func(schemaName string, entry types.AuditEntry) error
+
+// Store is the file-backed implementation of types.DBOperations. It has no
+// database root of its own: every Store in a process works on the one set by
+// NewStore or Configure and returned by DBPath. Run one kite process per data
+// directory to serve several.
+type Store struct {
+	audit AuditFunc
+	actor string
+}
+
+var _ types.DBOperations = (*Store)(nil)
+
+// StoreOption configures a Store in NewStore.
+type StoreOption func(*Store)
+
+// WithAudit has the Store pass an entry to fn after every write made
+// through it.
+func WithAudit(fn AuditFunc) StoreOption {
+	return func(s *Store) { s.audit = fn }
+}
+
+// WithActor sets the actor recorded in audit entries.
+func WithActor(actor string) StoreOption {
+	return func(s *Store) { s.actor = actor }
+}
+
+// NewStore returns a Store and makes cfg.DBPath the database root of the
+// whole process, replacing the root of any Store created earlier. Caches,
+// locks and undo queues are keyed by schema and collection name, so one
+// process serves one root at a time.
+func NewStore(cfg types.DBConfig, opts ...StoreOption) *Store {
+	path := cfg.DBPath
+	if path == "" {
+		path = DefaultDBPath
+	}
+	dbPathMu.Lock()
+	dbPath = path
+	dbPathMu.Unlock()
+
+	s := &Store{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// As returns a copy of s that records actor in audit entries, for writes
+// made on behalf of one request.
+func (s *Store) As(actor string) *Store {
+	c := *s
+	c.actor = actor
+	return &c
+}
+
+func (s *Store) AddCollection(collectionName, schemaName, jsonData string) error {
+	return s.AddCollectionAt(collectionName, schemaName, "", jsonData)
+}
+
+func (s *Store) AddCollectionAt(collectionName, schemaName, path, jsonData string) (err error) {
+	defer observe("add_collection", schemaName, collectionName, time.Now(), &err)
+	defer s.record("add", schemaName, collectionName, "", &err)
+	return AddCollectionAt(collectionName, schemaName, path, jsonData)
+}
+
+func (s *Store) InsertRecord(ctx context.Context, collectionName, jsonData, schemaName string) (err error) {
+	defer observe("insert", schemaName, collectionName, time.Now(), &err)
+	defer s.record("insert", schemaName, collectionName, "", &err)
+	return insertRecord(ctx, collectionName, jsonData, schemaName)
+}
+
+func (s *Store) EditCollection(ctx context.Context, collectionName, id, jsonData, schemaName, identity string) (changes []types.FieldChange, err error) {
+	defer observe("edit", schemaName, collectionName, time.Now(), &err)
+	defer s.record("edit", schemaName, collectionName, id, &err)
+	return editCollection(ctx, collectionName, id, jsonData, schemaName, identity)
+}
+
+func (s *Store) MoveRecord(ctx context.Context, collectionName, id, schemaName, identity string) (err error) {
+	defer observe("delete", schemaName, collectionName, time.Now(), &err)
+	defer s.record("delete", schemaName, collectionName, id, &err)
+	return moveRecord(ctx, collectionName, id, schemaName, identity)
+}
+
+func (s *Store) PullCollection(ctx context.Context, collectionName, schemaName string) error {
+	return pullCollection(ctx, collectionName, schemaName)
+}
+
+func (s *Store) DropCollection(collectionName, schemaName string) (err error) {
+	defer s.record("drop", schemaName, collectionName, "", &err)
+	return DropCollection(collectionName, schemaName)
+}
+
+func (s *Store) PatchRecord(ctx context.Context, collectionName, id, jsonData, schemaName, identity string) (changes []types.FieldChange, err error) {
+	defer s.record("patch", schemaName, collectionName, id, &err)
+	return PatchRecord(ctx, collectionName, id, jsonData, schemaName, identity)
+}
+
+func (s *Store) BulkInsertRecords(collectionName, schemaName string, rawRecords []json.RawMessage, partial bool, merge *types.MergeOptions) (successful []string, failures []types.BulkError, err error) {
+	defer func() {
+		s.recordDetails("bulk_insert", schemaName, collectionName, "", counts("inserted", len(successful), "failed", len(failures)), &err)
+	}()
+	return BulkInsertRecords(collectionName, schemaName, rawRecords, partial, merge)
+}
+
+func (s *Store) BulkUpsert(collectionName, schemaName, keyField string, records []map[string]interface{}) (inserted, updated int, errs []types.BulkError, err error) {
+	defer func() {
+		s.recordDetails("bulk_upsert", schemaName, collectionName, "", counts("inserted", inserted, "updated", updated, "failed", len(errs)), &err)
+	}()
+	return BulkUpsert(collectionName, schemaName, keyField, records)
+}
+
+func (s *Store) BatchPatch(collectionName, schemaName string, filter map[string]string, patch map[string]interface{}) (diffs []types.RecordDiff, err error) {
+	defer func() {
+		s.recordDetails("batch_patch", schemaName, collectionName, "", counts("modified", len(diffs)), &err)
+	}()
+	return BatchPatch(collectionName, schemaName, filter, patch)
+}
+
+func (s *Store) BatchEdit(collectionName, schemaName string, filter map[string]string, patch map[string]interface{}) (diffs []types.RecordDiff, err error) {
+	defer func() {
+		s.recordDetails("batch_edit", schemaName, collectionName, "", counts("modified", len(diffs)), &err)
+	}()
+	return BatchEdit(collectionName, schemaName, filter, patch)
+}
+
+func (s *Store) ImportCSV(collectionName, schemaName, inputPath string) (imported int, err error) {
+	defer func() {
+		s.recordDetails("import", schemaName, collectionName, "", counts("inserted", imported), &err)
+	}()
+	return ImportCSV(collectionName, schemaName, inputPath)
+}
+
+func (s *Store) ImportCollection(collectionName, schemaName, inputPath string) (imported int, err error) {
+	defer func() {
+		s.recordDetails("import", schemaName, collectionName, "", counts("inserted", imported), &err)
+	}()
+	return ImportCollection(collectionName, schemaName, inputPath)
+}
+
+func (s *Store) ImportWithDedup(collectionName, schemaName, dedupField string, records []map[string]interface{}) (inserted, skipped int, errs []error, err error) {
+	return s.ImportWithProgress(collectionName, schemaName, dedupField, records, nil)
+}
+
+func (s *Store) ImportWithProgress(collectionName, schemaName, dedupField string, records []map[string]interface{}, progress func(processed, inserted int)) (inserted, skipped int, errs []error, err error) {
+	defer func() {
+		s.recordDetails("import", schemaName, collectionName, "", counts("inserted", inserted, "skipped", skipped, "failed", len(errs)), &err)
+	}()
+	return ImportWithProgress(collectionName, schemaName, dedupField, records, progress)
+}
+
+func (s *Store) UndoLast(collectionName, schemaName string) (entry types.UndoEntry, err error) {
+	defer func() {
+		s.recordDetails("undo", schemaName, collectionName, "", map[string]string{"undone": entry.Op}, &err)
+	}()
+	return UndoLast(collectionName, schemaName)
+}
+
+func (s *Store) RestoreSnapshot(collectionName, schemaName, timestamp string) (err error) {
+	defer s.recordDetails("restore", schemaName, collectionName, "", map[string]string{"snapshot": timestamp}, &err)
+	return RestoreSnapshot(collectionName, schemaName, timestamp)
+}
+
+func (s *Store) MigrateSchema(collectionName, schemaName string, migration types.SchemaMigration) (modified int, err error) {
+	defer func() {
+		s.recordDetails("migrate", schemaName, collectionName, "", counts("modified", modified), &err)
+	}()
+	return MigrateSchema(collectionName, schemaName, migration)
+}
+
+func (s *Store) RepairCollection(collectionName, schemaName string) (saved, lost int, err error) {
+	defer func() {
+		s.recordDetails("repair", schemaName, collectionName, "", counts("saved", saved, "lost", lost), &err)
+	}()
+	return RepairCollection(collectionName, schemaName)
+}
+
+// RenameCollection is recorded under the old name, with the new one in the
+// entry's details.
+func (s *Store) RenameCollection(oldName, newName, schemaName string) (err error) {
+	defer s.recordDetails("rename", schemaName, oldName, "", map[string]string{"new_name": newName}, &err)
+	return RenameCollection(oldName, newName, schemaName)
+}
+
+// DropSchema is recorded in the audit log of the database root, since a
+// forced drop removes the schema's own log with it.
+func (s *Store) DropSchema(schemaName string, force bool) (err error) {
+	defer s.recordDetails("drop_schema", "", "", "", map[string]string{"schema": schemaName}, &err)
+	return DropSchema(schemaName, force)
+}
+
+func (s *Store) InsertMany(collectionName string, records []map[string]interface{}, schemaName string) (ids []string, err error) {
+	defer func() {
+		s.recordDetails("insert_many", schemaName, collectionName, "", counts("inserted", len(ids)), &err)
+	}()
+	return InsertMany(collectionName, records, schemaName)
+}
+
+// CopyCollection is recorded under the destination, with the source in the
+// entry's details.
+func (s *Store) CopyCollection(srcName, srcSchema, dstName, dstSchema string) (err error) {
+	defer s.recordDetails("copy", dstSchema, dstName, "", map[string]string{"source": srcSchema + "/" + srcName}, &err)
+	return CopyCollection(srcName, srcSchema, dstName, dstSchema)
+}
+
+// RekeyCollection is recorded with the checksum of the retired key, so a
+// backup can be matched with the key that opens it.
+func (s *Store) RekeyCollection(collectionName, schemaName string) (err error) {
+	var oldKeySum string
+	defer func() {
+		s.recordDetails("rekey", schemaName, collectionName, "", map[string]string{"old_key_sha256": oldKeySum}, &err)
+	}()
+	oldKeySum, err = rekeyCollection(collectionName, schemaName)
+	return err
+}
+
+func (s *Store) SetReadOnly(collectionName, schemaName string, readOnly, force bool) (err error) {
+	operation := "unfreeze"
+	if readOnly {
+		operation = "freeze"
+	}
+	defer s.recordDetails(operation, schemaName, collectionName, "", map[string]string{"force": strconv.FormatBool(force)}, &err)
+	return SetReadOnly(collectionName, schemaName, readOnly, force)
+}
+
+func (s *Store) LockRecord(collectionName, id, schemaName, identity, ttl string) (err error) {
+	defer s.recordDetails("lock_record", schemaName, collectionName, id, map[string]string{"owner": identity}, &err)
+	return LockRecord(collectionName, id, schemaName, identity, ttl)
+}
+
+func (s *Store) UnlockRecord(collectionName, id, schemaName, identity string) (err error) {
+	defer s.record("unlock_record", schemaName, collectionName, id, &err)
+	return UnlockRecord(collectionName, id, schemaName, identity)
+}
+
+func (s *Store) ChmodCollection(collectionName, schemaName string, fileMode, dirMode os.FileMode) (err error) {
+	details := map[string]string{"file_mode": fmt.Sprintf("%04o", fileMode.Perm()), "dir_mode": fmt.Sprintf("%04o", dirMode.Perm())}
+	defer s.recordDetails("chmod", schemaName, collectionName, "", details, &err)
+	return ChmodCollection(collectionName, schemaName, fileMode, dirMode)
+}
+
+// ForkCollection is recorded under the source collection, with the branch
+// name in the entry's details.
+func (s *Store) ForkCollection(src, branchName, schemaName string) (err error) {
+	defer s.recordDetails("fork", schemaName, src, "", map[string]string{"branch": branchName}, &err)
+	return ForkCollection(src, branchName, schemaName)
+}
+
+// ForkMerge is recorded under the target collection.
+func (s *Store) ForkMerge(branch, target, schemaName, strategy string) (err error) {
+	defer s.recordDetails("fork_merge", schemaName, target, "", map[string]string{"branch": branch, "strategy": strategy}, &err)
+	return ForkMerge(branch, target, schemaName, strategy)
+}
+
+func (s *Store) SetACL(collectionName, schemaName, identity string, permissions []string) (err error) {
+	details := map[string]string{"identity": identity, "permissions": strings.Join(permissions, ",")}
+	defer s.recordDetails("acl_set", schemaName, collectionName, "", details, &err)
+	return SetACL(collectionName, schemaName, identity, permissions)
+}
+
+func (s *Store) RevokeACL(collectionName, schemaName, identity string) (err error) {
+	defer s.recordDetails("acl_revoke", schemaName, collectionName, "", map[string]string{"identity": identity}, &err)
+	return RevokeACL(collectionName, schemaName, identity)
+}
+
+// SetSchemaACL is recorded in the schema's audit log with no collection.
+func (s *Store) SetSchemaACL(schemaName, identity string, permissions []string) (err error) {
+	details := map[string]string{"identity": identity, "permissions": strings.Join(permissions, ",")}
+	defer s.recordDetails("schema_acl_set", schemaName, "", "", details, &err)
+	return SetSchemaACL(schemaName, identity, permissions)
+}
+
+// counts renders name, value pairs as audit entry details.
+func counts(pairs ...interface{}) map[string]string {
+	details := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		details[pairs[i].(string)] = strconv.Itoa(pairs[i+1].(int))
+	}
+	return details
+}
+
+// record passes the outcome of a write to the audit callback, if any. A
+// failed audit write is reported but does not fail the operation.
+func (s *Store) record(operation, schemaName, collectionName, recordID string, err *error) {
+	s.recordDetails(operation, schemaName, collectionName, recordID, nil, err)
+}
+
+// recordDetails is record with details added to the entry.
+func (s *Store) recordDetails(operation, schemaName, collectionName, recordID string, details map[string]string, err *error) {
+	if s.audit == nil {
+		return
+	}
+	entry := types.AuditEntry{
+		Time:       time.Now().UTC(),
+		Operation:  operation,
+		Collection: collectionName,
+		RecordID:   recordID,
+		Actor:      s.actor,
+		Result:     "ok",
+		Details:    details,
+	}
+	if *err != nil {
+		entry.Result, entry.ErrorMessage = "error", (*err).Error()
+	}
+	if auditErr := s.audit(schemaName, entry); auditErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", auditErr)
+	}
+}
+
+// observe records an operation in the metrics when the deferred call runs,
+// so err must point at the caller's named result. Failures on collections
+// that do not exist are labelled "unknown", so that requests naming made-up
+// collections cannot add series without bound.
+func observe(operation, schemaName, collectionName string, start time.Time, err *error) {
+	if *err != nil && (ValidateNames(schemaName, collectionName) != nil || !collectionExists(collectionName, schemaName)) {
+		schemaName, collectionName = "unknown", "unknown"
+	}
+	metrics.ObserveOperation(operation, schemaName, collectionName, start, *err)
+}
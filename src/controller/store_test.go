@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"testing"
+
+	"kite/src/types"
+)
+
+// newTestStore points the controller at a fresh database root with a
+// "public" schema for one test.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64}
+	Configure(cfg)
+	s := NewStore(cfg)
+	if err := EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// addTestCollection creates a collection in the public schema.
+func addTestCollection(t *testing.T, s *Store, name, jsonData string) {
+	t.Helper()
+	if err := s.AddCollection(name, "public", jsonData); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreAuditsAdministrativeWrites(t *testing.T) {
+	newTestStore(t)
+	var entries []types.AuditEntry
+	s := NewStore(types.DBConfig{DBPath: DBPath(), CacheCapacity: 64}, WithAudit(func(schemaName string, entry types.AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}), WithActor("cli:ann"))
+	addTestCollection(t, s, "notes", `[{"title":"a"}]`)
+
+	steps := []struct {
+		operation string
+		run       func() error
+	}{
+		{"insert_many", func() error {
+			_, err := s.InsertMany("notes", []map[string]interface{}{{"title": "b"}}, "public")
+			return err
+		}},
+		{"copy", func() error { return s.CopyCollection("notes", "public", "copy", "public") }},
+		{"rekey", func() error { return s.RekeyCollection("notes", "public") }},
+		{"fork", func() error { return s.ForkCollection("notes", "draft", "public") }},
+		{"fork_merge", func() error { return s.ForkMerge("notes-draft", "notes", "public", "merge") }},
+		{"chmod", func() error { return s.ChmodCollection("notes", "public", 0600, 0700) }},
+		{"acl_set", func() error { return s.SetACL("notes", "public", "apikey:ops", []string{PermRead}) }},
+		{"acl_revoke", func() error { return s.RevokeACL("notes", "public", "apikey:ops") }},
+		{"schema_acl_set", func() error { return s.SetSchemaACL("public", "apikey:ops", []string{PermRead}) }},
+		{"freeze", func() error { return s.SetReadOnly("notes", "public", true, false) }},
+		{"unfreeze", func() error { return s.SetReadOnly("notes", "public", false, false) }},
+	}
+	for _, step := range steps {
+		entries = nil
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.operation, err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("%s: %d audit entries, want 1", step.operation, len(entries))
+		}
+		if entry := entries[0]; entry.Operation != step.operation || entry.Actor != "cli:ann" || entry.Result != "ok" {
+			t.Errorf("%s: entry = %+v", step.operation, entry)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+	"kite/src/undo"
+)
+
+// UndoLast restores the collection file to its contents before the most
+// recent write made by this process.
+func UndoLast(collectionName, schemaName string) (types.UndoEntry, error) {
+	if err := checkWritable(collectionName, schemaName); err != nil {
+		return types.UndoEntry{}, err
+	}
+
+	unlock, err := lockCollection(collectionName, schemaName)
+	if err != nil {
+		return types.UndoEntry{}, err
+	}
+	defer unlock()
+
+	key := undo.Key(schemaName, collectionName)
+	entry, ok := undo.Pop(key)
+	if !ok {
+		return entry, kerrors.New(kerrors.ErrNothingToUndo, "nothing to undo for collection %s", collectionName)
+	}
+
+	collectionPath := filepath.Join(dataDir(collectionName, schemaName), collectionName+".txt")
+	current, err := os.ReadFile(collectionPath)
+	if err != nil {
+		return entry, collectionReadError(err)
+	}
+	if !bytes.Equal(current, entry.After) {
+		undo.Clear(key)
+		return entry, kerrors.New(kerrors.ErrVersionConflict, "collection %s was modified outside this server; undo history cleared", collectionName)
+	}
+
+	if err := writeCollectionAtomic(collectionPath, entry.Before); err != nil {
+		return entry, fmt.Errorf("failed to write collection file: %v", err)
+	}
+
+	fmt.Printf("Undid %s on collection %s\n", entry.Op, collectionName)
+	return entry, nil
+}
+
+func UndoHistory(collectionName, schemaName string) []types.UndoEntry {
+	return undo.History(undo.Key(schemaName, collectionName))
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	kerrors "kite/src/errors"
+	"kite/src/undo"
+)
+
+func TestUndoLastInsert(t *testing.T) {
+	s := newTestStore(t)
+	addTestCollection(t, s, "drafts", `[{"title":"a"}]`)
+	undo.Clear(undo.Key("public", "drafts"))
+
+	if err := s.InsertRecord(context.Background(), "drafts", `{"title":"b"}`, "public"); err != nil {
+		t.Fatal(err)
+	}
+	if history := UndoHistory("drafts", "public"); len(history) != 1 || history[0].Op != "insert" {
+		t.Fatalf("undo history = %+v, want one insert", history)
+	}
+
+	entry, err := s.UndoLast("drafts", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Op != "insert" {
+		t.Errorf("undone %q, want insert", entry.Op)
+	}
+	records, err := ReadCollection("drafts", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0]["title"] != "a" {
+		t.Errorf("records after undo = %v, want only a", records)
+	}
+
+	if _, err := s.UndoLast("drafts", "public"); kerrors.Code(err) != kerrors.ErrNothingToUndo {
+		t.Errorf("second undo = %v, want %s", err, kerrors.ErrNothingToUndo)
+	}
+}
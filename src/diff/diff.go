@@ -0,0 +1,60 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"kite/src/types"
+)
+
+const (
+	red   = "\033[31m"
+	green = "\033[32m"
+	reset = "\033[0m"
+)
+
+// RecordDiff lists the fields that differ between before and after, sorted
+// by field name.
+func RecordDiff(before, after types.Record) []types.FieldChange {
+	var changes []types.FieldChange
+	for k, b := range before {
+		a, ok := after[k]
+		switch {
+		case !ok:
+			changes = append(changes, types.FieldChange{Field: k, Before: b, Op: "remove"})
+		case !reflect.DeepEqual(a, b):
+			changes = append(changes, types.FieldChange{Field: k, Before: b, After: a, Op: "change"})
+		}
+	}
+	for k, a := range after {
+		if _, ok := before[k]; !ok {
+			changes = append(changes, types.FieldChange{Field: k, After: a, Op: "add"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// Write prints changes as "- field: old" and "+ field: new" lines, in red
+// and green when color is set.
+func Write(w io.Writer, changes []types.FieldChange, color bool) {
+	line := func(sign, c, field string, value interface{}) {
+		data, _ := json.Marshal(value)
+		if color {
+			fmt.Fprintf(w, "%s%s %s: %s%s\n", c, sign, field, data, reset)
+		} else {
+			fmt.Fprintf(w, "%s %s: %s\n", sign, field, data)
+		}
+	}
+	for _, change := range changes {
+		if change.Op != "add" {
+			line("-", red, change.Field, change.Before)
+		}
+		if change.Op != "remove" {
+			line("+", green, change.Field, change.After)
+		}
+	}
+}
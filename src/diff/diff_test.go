@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestRecordDiff(t *testing.T) {
+	before := types.Record{"name": "alice", "age": 30.0, "city": "paris"}
+	after := types.Record{"name": "alice_new", "age": 30.0, "email": "a@example.com"}
+
+	want := []types.FieldChange{
+		{Field: "city", Before: "paris", Op: "remove"},
+		{Field: "email", After: "a@example.com", Op: "add"},
+		{Field: "name", Before: "alice", After: "alice_new", Op: "change"},
+	}
+	if got := RecordDiff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("RecordDiff = %+v, want %+v", got, want)
+	}
+	if got := RecordDiff(before, before); len(got) != 0 {
+		t.Errorf("RecordDiff of equal records = %+v, want none", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	changes := []types.FieldChange{
+		{Field: "city", Before: "paris", Op: "remove"},
+		{Field: "name", Before: "alice", After: "alice_new", Op: "change"},
+	}
+
+	var buf bytes.Buffer
+	Write(&buf, changes, false)
+	want := "- city: \"paris\"\n- name: \"alice\"\n+ name: \"alice_new\"\n"
+	if buf.String() != want {
+		t.Errorf("Write = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	Write(&buf, changes[1:], true)
+	want = red + "- name: \"alice\"" + reset + "\n" + green + "+ name: \"alice_new\"" + reset + "\n"
+	if buf.String() != want {
+		t.Errorf("colored Write = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,60 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+)
+
+const (
+	ErrInternal             = "ERR_INTERNAL"
+	ErrInvalidRequest       = "ERR_INVALID_REQUEST"
+	ErrInvalidJSON          = "ERR_INVALID_JSON"
+	ErrInvalidConnection    = "ERR_INVALID_CONNECTION"
+	ErrRecordNotFound       = "ERR_RECORD_NOT_FOUND"
+	ErrRecordLocked         = "ERR_RECORD_LOCKED"
+	ErrLockTimeout          = "ERR_LOCK_TIMEOUT"
+	ErrCollectionNotFound   = "ERR_COLLECTION_NOT_FOUND"
+	ErrCollectionExists     = "ERR_COLLECTION_EXISTS"
+	ErrCollectionExpired    = "ERR_COLLECTION_EXPIRED"
+	ErrSchemaNotFound       = "ERR_SCHEMA_NOT_FOUND"
+	ErrSchemaExists         = "ERR_SCHEMA_EXISTS"
+	ErrSchemaNotEmpty       = "ERR_SCHEMA_NOT_EMPTY"
+	ErrSnapshotNotFound     = "ERR_SNAPSHOT_NOT_FOUND"
+	ErrJobNotFound          = "ERR_JOB_NOT_FOUND"
+	ErrSubscriptionNotFound = "ERR_SUBSCRIPTION_NOT_FOUND"
+	ErrBulkInsertFailed     = "ERR_BULK_INSERT_FAILED"
+	ErrVersionConflict      = "ERR_VERSION_CONFLICT"
+	ErrMergeConflict        = "ERR_MERGE_CONFLICT"
+	ErrNothingToUndo        = "ERR_NOTHING_TO_UNDO"
+	ErrSchemaValidation     = "ERR_SCHEMA_VALIDATION"
+	ErrEventSourced         = "ERR_EVENT_SOURCED"
+	ErrUnauthorized         = "ERR_UNAUTHORIZED"
+	ErrForbidden            = "ERR_FORBIDDEN"
+	ErrRateLimited          = "ERR_RATE_LIMITED"
+	ErrReadOnly             = "ERR_READ_ONLY"
+	ErrCapacityExceeded     = "ERR_CAPACITY_EXCEEDED"
+	ErrNotLeader            = "ERR_NOT_LEADER"
+)
+
+// Error carries a machine-readable code alongside the human message.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Code returns the code attached to err, or ErrInternal if there is none.
+func Code(err error) string {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e.Code
+	}
+	return ErrInternal
+}
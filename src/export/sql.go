@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"kite/src/controller"
+	"kite/src/helper"
+)
+
+// sqlLiteral renders a value produced by columnValue as a SQL literal.
+func sqlLiteral(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case string:
+		return "'" + strings.ReplaceAll(val, "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+}
+
+// ToSQL writes a CREATE TABLE statement followed by one INSERT per record.
+// Columns are the union of the record fields; nested values are written as
+// JSON strings.
+func ToSQL(collectionName, schemaName, tableName string, w io.Writer) error {
+	records, err := readCollectionDir(controller.CollectionPath(schemaName, collectionName, false), collectionName, schemaName)
+	if err != nil {
+		return err
+	}
+	if tableName == "" {
+		tableName = collectionName
+	}
+
+	columns, defs := tableColumns(tableName, records)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "CREATE TABLE IF NOT EXISTS %s (%s);\n", quoteIdent(tableName), strings.Join(defs, ", "))
+
+	for _, record := range records {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			v, ok := record[col]
+			if col == "_version" {
+				values[i] = "NULL"
+				if version, err := helper.ToFloat64(v); err == nil {
+					values[i] = sqlLiteral(int64(version))
+				}
+				continue
+			}
+			value, err := columnValue(v, ok)
+			if err != nil {
+				return fmt.Errorf("failed to convert field %s of record %v: %v", col, record["_id"], err)
+			}
+			values[i] = sqlLiteral(value)
+		}
+		fmt.Fprintf(bw, "INSERT INTO %s VALUES (%s);\n", quoteIdent(tableName), strings.Join(values, ", "))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"kite/src/controller"
+	"kite/src/types"
+)
+
+func TestToSQLRunsInSQLite(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64}
+	controller.Configure(cfg)
+	controller.NewStore(cfg)
+	if err := controller.EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := controller.AddCollectionAt("people", "public", "", `[
+		{"name":"O'Brien","age":41,"address":{"city":"Cork"}},
+		{"name":"ann; DROP TABLE users; --","active":true},
+		{"name":"bob"}
+	]`); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := ToSQL("people", "public", "users", &out); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(out.String()); err != nil {
+		t.Fatalf("generated SQL does not run: %v\n%s", err, out.String())
+	}
+
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM "users"`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 3 {
+		t.Errorf("users has %d rows, want 3", rows)
+	}
+	var age, address sql.NullString
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT "age", "address", "_version" FROM "users" WHERE "name" = 'O''Brien'`).Scan(&age, &address, &version); err != nil {
+		t.Fatal(err)
+	}
+	if age.String != "41" || address.String != `{"city":"Cork"}` || !version.Valid {
+		t.Errorf("O'Brien = age %v, address %v, version %v", age, address, version)
+	}
+	if err := db.QueryRow(`SELECT "age" FROM "users" WHERE "name" = 'bob'`).Scan(&age); err != nil || age.Valid {
+		t.Errorf("missing field = %v, %v; want NULL", age, err)
+	}
+
+	if err := ToSQL("missing", "public", "users", &out); err == nil {
+		t.Error("exporting a missing collection succeeded")
+	}
+}
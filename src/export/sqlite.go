@@ -0,0 +1,199 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kite/src/controller"
+	"kite/src/helper"
+	"kite/src/types"
+
+	_ "modernc.org/sqlite"
+)
+
+var metadataColumns = []struct {
+	field, column, sqlType string
+}{
+	{"_id", "_id", "TEXT PRIMARY KEY"},
+	{"createdAt", "created_at", "TEXT"},
+	{"updatedAt", "updated_at", "TEXT"},
+	{"_version", "_version", "INTEGER"},
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func readCollectionDir(dir, collectionName, schemaName string) ([]types.Record, error) {
+	encryptedData, err := os.ReadFile(filepath.Join(dir, collectionName+".txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection file: %v", err)
+	}
+
+	decrypted, err := controller.DecryptCollectionData(dir, collectionName, schemaName, encryptedData)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []types.Record
+	if err := json.Unmarshal(decrypted, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse collection JSON: %v", err)
+	}
+	return records, nil
+}
+
+// dataFields returns the union of non-metadata keys across records, sorted.
+func dataFields(records []types.Record) []string {
+	seen := make(map[string]bool)
+	for _, record := range records {
+		for k := range record {
+			seen[k] = true
+		}
+	}
+	for _, m := range metadataColumns {
+		delete(seen, m.field)
+	}
+
+	fields := make([]string, 0, len(seen))
+	for k := range seen {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// columnValue converts a record value into something SQLite can store.
+// Missing values become NULL and nested values are stored as JSON.
+func columnValue(v interface{}, ok bool) (interface{}, error) {
+	if !ok || v == nil {
+		return nil, nil
+	}
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+}
+
+// tableColumns returns the record fields to export, in column order, and
+// their column definitions. Fields whose names clash with an earlier column
+// are skipped with a warning.
+func tableColumns(table string, records []types.Record) ([]string, []string) {
+	fields := dataFields(records)
+
+	columns := make([]string, 0, len(metadataColumns)+len(fields))
+	defs := make([]string, 0, cap(columns))
+	taken := make(map[string]bool)
+	for _, m := range metadataColumns {
+		columns = append(columns, m.field)
+		defs = append(defs, quoteIdent(m.column)+" "+m.sqlType)
+		taken[strings.ToLower(m.column)] = true
+	}
+	for _, f := range fields {
+		if taken[strings.ToLower(f)] {
+			fmt.Fprintf(os.Stderr, "Warning: skipping field %q in %s: column name already in use\n", f, table)
+			continue
+		}
+		taken[strings.ToLower(f)] = true
+		columns = append(columns, f)
+		defs = append(defs, quoteIdent(f)+" TEXT")
+	}
+	return columns, defs
+}
+
+func exportTable(db *sql.DB, table string, records []types.Record) error {
+	columns, defs := tableColumns(table, records)
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table), strings.Join(defs, ", "))); err != nil {
+		return fmt.Errorf("failed to create table %s: %v", table, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteIdent(table), placeholders))
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert for %s: %v", table, err)
+	}
+	defer stmt.Close()
+
+	for _, record := range records {
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			v, ok := record[col]
+			if col == "_version" {
+				if version, err := helper.ToFloat64(v); err == nil {
+					values[i] = int64(version)
+				}
+				continue
+			}
+			if values[i], err = columnValue(v, ok); err != nil {
+				return fmt.Errorf("failed to convert field %s of record %v: %v", col, record["_id"], err)
+			}
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return fmt.Errorf("failed to insert record %v into %s: %v", record["_id"], table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ToSQLite writes every collection in schemaName to a new SQLite database at
+// outputPath, one table per collection.
+func ToSQLite(dbDir, outputPath, schemaName string) error {
+	dir := dbDir
+	if schemaName != "" {
+		dir = filepath.Join(dbDir, schemaName)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read schema directory: %v", err)
+	}
+
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove existing output file: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open SQLite database: %v", err)
+	}
+	defer db.Close()
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".txt" {
+			continue
+		}
+		collectionName := strings.TrimSuffix(entry.Name(), ".txt")
+
+		records, err := readCollectionDir(dir, collectionName, schemaName)
+		if err != nil {
+			return fmt.Errorf("collection %s: %v", collectionName, err)
+		}
+		if err := exportTable(db, collectionName, records); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d records from %s\n", len(records), collectionName)
+	}
+	return nil
+}
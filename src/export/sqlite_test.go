@@ -0,0 +1,31 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestExportTableVersionWithJSONNumbers(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "out.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	records := []types.Record{{"_id": "a", "_version": json.Number("4"), "title": "x"}}
+	if err := exportTable(db, "notes", records); err != nil {
+		t.Fatal(err)
+	}
+
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT "_version" FROM "notes"`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if !version.Valid || version.Int64 != 4 {
+		t.Errorf("_version = %v, want 4", version)
+	}
+}
@@ -0,0 +1,80 @@
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrTimeout is returned by LockTimeout when the lock is still held by
+// someone else once the timeout has passed.
+var ErrTimeout = errors.New("timed out waiting for lock")
+
+// Lock blocks until it holds an exclusive lock on path, creating the file if
+// needed. Call the returned function to release it.
+func Lock(path string) (unlock func(), err error) {
+	return acquire(path, true)
+}
+
+// LockTimeout is Lock but gives up with ErrTimeout after timeout, polling with
+// an increasing backoff while another process holds the lock.
+func LockTimeout(path string, timeout time.Duration) (unlock func(), err error) {
+	return acquireTimeout(path, true, timeout)
+}
+
+// RLock blocks until it holds a shared lock on path. Any number of shared
+// locks may be held at once, but not alongside an exclusive one.
+func RLock(path string) (unlock func(), err error) {
+	return acquire(path, false)
+}
+
+// RLockTimeout is RLock but gives up with ErrTimeout after timeout.
+func RLockTimeout(path string, timeout time.Duration) (unlock func(), err error) {
+	return acquireTimeout(path, false, timeout)
+}
+
+func acquireTimeout(path string, exclusive bool, timeout time.Duration) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %v", path, err)
+	}
+	deadline := time.Now().Add(timeout)
+	backoff := time.Millisecond
+	for {
+		locked, err := tryLockFile(f, exclusive)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+		if locked {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrTimeout
+		}
+		time.Sleep(backoff)
+		if backoff < 100*time.Millisecond {
+			backoff *= 2
+		}
+	}
+}
+
+func acquire(path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %v", path, err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
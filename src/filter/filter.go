@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"kite/src/helper"
+	"kite/src/types"
+)
+
+// operators maps the operator syntax accepted by ParseFilter to the op name
+// stored in a FilterExpression.
+var operators = map[string]string{
+	"=":  "eq",
+	"!=": "ne",
+	">":  "gt",
+	"<":  "lt",
+	">=": "gte",
+	"<=": "lte",
+}
+
+// ParseFilter parses a single field<op>value expression such as status=active
+// or age>=18.
+func ParseFilter(expr string) (types.FilterExpression, error) {
+	expr = strings.TrimSpace(expr)
+	start := strings.IndexAny(expr, "=!<>")
+	if start <= 0 {
+		return types.FilterExpression{}, fmt.Errorf("invalid filter expression %q", expr)
+	}
+	end := start
+	for end < len(expr) && strings.ContainsRune("=!<>", rune(expr[end])) {
+		end++
+	}
+
+	op, ok := operators[expr[start:end]]
+	if !ok {
+		return types.FilterExpression{}, fmt.Errorf("invalid operator %q in filter expression %q", expr[start:end], expr)
+	}
+	field := strings.TrimSpace(expr[:start])
+	if field == "" {
+		return types.FilterExpression{}, fmt.Errorf("invalid filter expression %q", expr)
+	}
+	return types.FilterExpression{Field: field, Op: op, Value: strings.TrimSpace(expr[end:])}, nil
+}
+
+// Match reports whether record satisfies every expression.
+func Match(record types.Record, exprs []types.FilterExpression) bool {
+	for _, expr := range exprs {
+		value, ok := record[expr.Field]
+		switch expr.Op {
+		case "eq", "ne":
+			equal := ok && compareToValue(value, expr.Value) == 0
+			if equal != (expr.Op == "eq") {
+				return false
+			}
+		default:
+			if !ok || value == nil {
+				return false
+			}
+			cmp := compareToValue(value, expr.Value)
+			switch expr.Op {
+			case "gt":
+				ok = cmp > 0
+			case "lt":
+				ok = cmp < 0
+			case "gte":
+				ok = cmp >= 0
+			case "lte":
+				ok = cmp <= 0
+			default:
+				ok = false
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareToValue compares a record value with the string from a filter
+// expression, numerically when both sides are numbers.
+func compareToValue(value interface{}, s string) int {
+	if x, err := helper.ToFloat64(value); err == nil {
+		if y, err := strconv.ParseFloat(s, 64); err == nil {
+			return Compare(x, y)
+		}
+	}
+	return strings.Compare(fmt.Sprint(value), s)
+}
+
+// Compare orders numbers numerically and everything else by its string form;
+// missing values sort first.
+func Compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, err := helper.ToFloat64(a); err == nil {
+		if y, err := helper.ToFloat64(b); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
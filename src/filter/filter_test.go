@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want types.FilterExpression
+	}{
+		{"status=active", types.FilterExpression{Field: "status", Op: "eq", Value: "active"}},
+		{"role!=admin", types.FilterExpression{Field: "role", Op: "ne", Value: "admin"}},
+		{"age>18", types.FilterExpression{Field: "age", Op: "gt", Value: "18"}},
+		{"age<65", types.FilterExpression{Field: "age", Op: "lt", Value: "65"}},
+		{" age >= 18 ", types.FilterExpression{Field: "age", Op: "gte", Value: "18"}},
+		{"score<=4.5", types.FilterExpression{Field: "score", Op: "lte", Value: "4.5"}},
+		{"note=", types.FilterExpression{Field: "note", Op: "eq", Value: ""}},
+	}
+	for _, tt := range tests {
+		got, err := ParseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFilter(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"status", "=active", "age=>18", "age<>18", "age==18", "a!b"} {
+		if got, err := ParseFilter(expr); err == nil {
+			t.Errorf("ParseFilter(%q) = %+v, want an error", expr, got)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	record := types.Record{"status": "active", "age": 30.0, "name": "ann"}
+	parse := func(exprs ...string) []types.FilterExpression {
+		var out []types.FilterExpression
+		for _, expr := range exprs {
+			f, err := ParseFilter(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, f)
+		}
+		return out
+	}
+
+	for _, exprs := range [][]string{{"status=active", "age>=18"}, {"age<100"}, {"age>9"}, {"role!=admin"}, {"name<bob"}} {
+		if !Match(record, parse(exprs...)) {
+			t.Errorf("%v does not match %v", exprs, record)
+		}
+	}
+	for _, exprs := range [][]string{{"status=active", "age<18"}, {"status!=active"}, {"missing>1"}, {"age>100"}} {
+		if Match(record, parse(exprs...)) {
+			t.Errorf("%v matches %v", exprs, record)
+		}
+	}
+}
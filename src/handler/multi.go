@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+// MaxBatchRequests caps the number of sub-requests in one multi call.
+const MaxBatchRequests = 50
+
+// connectionFields are copied from the outer request body into each
+// sub-request that doesn't set them itself.
+var connectionFields = []string{"username", "password", "host", "port", "schema_name", "connection_string"}
+
+// MultiBatch runs each sub-request against h in order and collects the
+// responses. Sub-requests are independent: one failing does not stop or undo
+// the others. Headers and connection fields from the outer request are
+// passed on so every sub-request is authenticated the same way.
+func MultiBatch(h http.Handler, header http.Header, connection map[string]json.RawMessage, requests []types.BatchRequest) ([]types.BatchResponse, error) {
+	if len(requests) == 0 {
+		return nil, kerrors.New(kerrors.ErrInvalidRequest, "requests must not be empty")
+	}
+	if len(requests) > MaxBatchRequests {
+		return nil, kerrors.New(kerrors.ErrInvalidRequest, "too many requests: %d (max %d)", len(requests), MaxBatchRequests)
+	}
+	for i, req := range requests {
+		if !strings.HasPrefix(req.Path, "/v1/") && !strings.HasPrefix(req.Path, "/v2/") {
+			return nil, kerrors.New(kerrors.ErrInvalidRequest, "request %d: path must start with /v1/ or /v2/", i)
+		}
+		if strings.HasSuffix(strings.SplitN(req.Path, "?", 2)[0], "/multi") {
+			return nil, kerrors.New(kerrors.ErrInvalidRequest, "request %d: multi requests cannot be nested", i)
+		}
+	}
+
+	responses := make([]types.BatchResponse, len(requests))
+	for i, req := range requests {
+		body, err := subRequestBody(req.Body, connection)
+		if err != nil {
+			responses[i] = errorResponse(http.StatusBadRequest, err)
+			continue
+		}
+		method := strings.ToUpper(req.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+		sub, err := http.NewRequest(method, req.Path, bytes.NewReader(body))
+		if err != nil {
+			responses[i] = errorResponse(http.StatusBadRequest, err)
+			continue
+		}
+		for name, values := range header {
+			if name != "Content-Length" {
+				sub.Header[name] = values
+			}
+		}
+		sub.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, sub)
+		responses[i] = types.BatchResponse{Status: rec.Code, Body: responseBody(rec.Body.Bytes())}
+	}
+	return responses, nil
+}
+
+func subRequestBody(raw json.RawMessage, connection map[string]json.RawMessage) ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, kerrors.New(kerrors.ErrInvalidRequest, "body must be a JSON object: %v", err)
+		}
+	}
+	for _, name := range connectionFields {
+		if _, ok := fields[name]; !ok {
+			if v, ok := connection[name]; ok {
+				fields[name] = v
+			}
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// responseBody keeps JSON responses as-is and wraps anything else, such as
+// file downloads, in a JSON string.
+func responseBody(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return data
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
+
+func errorResponse(status int, err error) types.BatchResponse {
+	body, _ := json.Marshal(map[string]string{"error": err.Error(), "code": kerrors.Code(err)})
+	return types.BatchResponse{Status: status, Body: body}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 )
 
+// GenerateKey returns a random 32-byte key, selecting AES-256.
 func GenerateKey() ([]byte, error) {
 	key := make([]byte, 32)
 	_, err := rand.Read(key)
@@ -17,6 +18,8 @@ func GenerateKey() ([]byte, error) {
 	return key, nil
 }
 
+// Encrypt seals data with AES-GCM under key and returns the random nonce
+// followed by the ciphertext and tag, base64 encoded.
 func Encrypt(data, key []byte) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -37,6 +40,8 @@ func Encrypt(data, key []byte) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
+// Decrypt opens data produced by Encrypt. GCM is authenticated, so tampered
+// or truncated data fails instead of decrypting to garbage.
 func Decrypt(encryptedData string, key []byte) ([]byte, error) {
 	data, err := base64.StdEncoding.DecodeString(encryptedData)
 	if err != nil {
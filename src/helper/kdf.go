@@ -0,0 +1,26 @@
+package helper
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters for DeriveKey, following the RFC 9106 second
+// recommended option.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+)
+
+// DeriveKey derives a 32-byte key from password and salt with Argon2id.
+func DeriveKey(password, salt []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, fmt.Errorf("password is required to derive a key")
+	}
+	if len(salt) < 8 {
+		return nil, fmt.Errorf("salt must be at least 8 bytes")
+	}
+	return argon2.IDKey(password, salt, argon2Time, argon2Memory, argon2Threads, 32), nil
+}
@@ -0,0 +1,22 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ToFloat64 converts a decoded JSON number, either a float64 or a
+// json.Number, to a float64.
+func ToFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case json.Number:
+		return n.Float64()
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	}
+	return 0, fmt.Errorf("%v is not a number", v)
+}
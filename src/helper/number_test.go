@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToFloat64(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want float64
+		ok   bool
+	}{
+		{2.5, 2.5, true},
+		{json.Number("41"), 41, true},
+		{json.Number("1e3"), 1000, true},
+		{7, 7, true},
+		{int64(-3), -3, true},
+		{"41", 0, false},
+		{json.Number("forty"), 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ToFloat64(tt.in)
+		if tt.ok && (err != nil || got != tt.want) {
+			t.Errorf("ToFloat64(%#v) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("ToFloat64(%#v) = %v, want an error", tt.in, got)
+		}
+	}
+}
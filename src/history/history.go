@@ -0,0 +1,200 @@
+package history
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/types"
+)
+
+const (
+	DefaultSnapshots = 10
+	dirName          = ".history"
+	snapSuffix       = ".snap"
+)
+
+var (
+	fileMode os.FileMode = 0600
+	dirMode  os.FileMode = 0700
+)
+
+// SetPermissions changes the modes given to new snapshot files and
+// directories.
+func SetPermissions(file, dir os.FileMode) {
+	fileMode, dirMode = file, dir
+}
+
+// snapshotFile is the gzipped body of a .snap file. Data is the collection
+// file as written, so it stays encrypted with the collection key.
+type snapshotFile struct {
+	Operation   string `json:"operation"`
+	RecordCount int    `json:"record_count"`
+	Data        []byte `json:"data"`
+}
+
+// Dir returns where snapshots of collectionName are kept inside schemaDir.
+func Dir(schemaDir, collectionName string) string {
+	return filepath.Join(schemaDir, dirName, collectionName)
+}
+
+// SaveSnapshot stores data as the newest snapshot of collectionName and
+// prunes all but the keep most recent ones.
+func SaveSnapshot(schemaDir, collectionName, op string, recordCount int, data []byte, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	dir := Dir(schemaDir, collectionName)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create history directory %s: %v", dir, err)
+	}
+
+	path := filepath.Join(dir, strconv.FormatInt(time.Now().UnixNano(), 10)+snapSuffix)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %v", err)
+	}
+	zw := gzip.NewWriter(f)
+	encodeErr := json.NewEncoder(zw).Encode(snapshotFile{Operation: op, RecordCount: recordCount, Data: data})
+	if err := zw.Close(); encodeErr == nil {
+		encodeErr = err
+	}
+	if err := f.Close(); encodeErr == nil {
+		encodeErr = err
+	}
+	if encodeErr != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write snapshot: %v", encodeErr)
+	}
+
+	return prune(dir, keep)
+}
+
+// timestamps returns the snapshot timestamps in dir, oldest first.
+func timestamps(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %v", err)
+	}
+
+	var stamps []int64
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapSuffix)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(name, 10, 64); err == nil {
+			stamps = append(stamps, n)
+		}
+	}
+	sort.Slice(stamps, func(i, j int) bool { return stamps[i] < stamps[j] })
+
+	out := make([]string, len(stamps))
+	for i, n := range stamps {
+		out[i] = strconv.FormatInt(n, 10)
+	}
+	return out, nil
+}
+
+func prune(dir string, keep int) error {
+	stamps, err := timestamps(dir)
+	if err != nil {
+		return err
+	}
+	for len(stamps) > keep {
+		if err := os.Remove(filepath.Join(dir, stamps[0]+snapSuffix)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old snapshot: %v", err)
+		}
+		stamps = stamps[1:]
+	}
+	return nil
+}
+
+func readSnapshot(path string) (snapshotFile, error) {
+	var snap snapshotFile
+	f, err := os.Open(path)
+	if err != nil {
+		return snap, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return snap, fmt.Errorf("failed to decompress snapshot: %v", err)
+	}
+	defer zr.Close()
+	if err := json.NewDecoder(zr).Decode(&snap); err != nil {
+		return snap, fmt.Errorf("failed to parse snapshot: %v", err)
+	}
+	return snap, nil
+}
+
+// ListSnapshots returns the snapshots of collectionName, newest first.
+func ListSnapshots(schemaDir, collectionName string) ([]types.Snapshot, error) {
+	dir := Dir(schemaDir, collectionName)
+	stamps, err := timestamps(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]types.Snapshot, 0, len(stamps))
+	for i := len(stamps) - 1; i >= 0; i-- {
+		path := filepath.Join(dir, stamps[i]+snapSuffix)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		snap, err := readSnapshot(path)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, types.Snapshot{
+			Timestamp:   stamps[i],
+			Operation:   snap.Operation,
+			RecordCount: snap.RecordCount,
+			SizeBytes:   info.Size(),
+		})
+	}
+	return snapshots, nil
+}
+
+// LoadSnapshot returns the encrypted collection data stored at timestamp.
+func LoadSnapshot(schemaDir, collectionName, timestamp string) ([]byte, error) {
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		return nil, kerrors.New(kerrors.ErrInvalidRequest, "invalid snapshot timestamp %q", timestamp)
+	}
+	snap, err := readSnapshot(filepath.Join(Dir(schemaDir, collectionName), timestamp+snapSuffix))
+	if os.IsNotExist(err) {
+		return nil, kerrors.New(kerrors.ErrSnapshotNotFound, "no snapshot %s for collection %s", timestamp, collectionName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snap.Data, nil
+}
+
+// Rename moves the history of oldName over to newName.
+func Rename(schemaDir, oldName, newName string) error {
+	if err := os.Rename(Dir(schemaDir, oldName), Dir(schemaDir, newName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rename history: %v", err)
+	}
+	return nil
+}
+
+// Remove deletes every snapshot of collectionName.
+func Remove(schemaDir, collectionName string) error {
+	if err := os.RemoveAll(Dir(schemaDir, collectionName)); err != nil {
+		return fmt.Errorf("failed to delete history: %v", err)
+	}
+	return nil
+}
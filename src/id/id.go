@@ -0,0 +1,50 @@
+// Package id generates and checks record identifiers.
+package id
+
+import (
+	"strconv"
+
+	kerrors "kite/src/errors"
+
+	"github.com/google/uuid"
+)
+
+// GenerateID returns a UUIDv7. Its leading timestamp makes IDs from one
+// process increase monotonically, so records sort by creation order.
+func GenerateID() string {
+	u, err := uuid.NewV7()
+	if err != nil {
+		return uuid.New().String()
+	}
+	return u.String()
+}
+
+// Validate checks that s is a record ID: a UUID of any version, an integer,
+// or a 24-digit hex MongoDB ObjectId kept by the importer.
+func Validate(s string) error {
+	if s == "" {
+		return kerrors.New(kerrors.ErrInvalidRequest, "record id must not be empty")
+	}
+	if _, err := uuid.Parse(s); err == nil {
+		return nil
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return nil
+	}
+	if isObjectID(s) {
+		return nil
+	}
+	return kerrors.New(kerrors.ErrInvalidRequest, "invalid record id %q: expected a UUID, an integer or a MongoDB ObjectId", s)
+}
+
+func isObjectID(s string) bool {
+	if len(s) != 24 {
+		return false
+	}
+	for _, r := range s {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package id
+
+import (
+	"testing"
+
+	kerrors "kite/src/errors"
+)
+
+func TestGenerateIDIncreases(t *testing.T) {
+	prev := GenerateID()
+	for i := 0; i < 1000; i++ {
+		next := GenerateID()
+		if err := Validate(next); err != nil {
+			t.Fatalf("generated id %q is invalid: %v", next, err)
+		}
+		if next <= prev {
+			t.Fatalf("id %q did not sort after %q", next, prev)
+		}
+		prev = next
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		ok   bool
+	}{
+		{"uuid v4", "9b2f6c1e-3a4d-4e5f-8a7b-1c2d3e4f5a6b", true},
+		{"uuid v7", GenerateID(), true},
+		{"integer", "42", true},
+		{"negative integer", "-7", true},
+		{"object id", "5f1d7a8b9c0d1e2f3a4b5c6d", true},
+		{"upper case object id", "5F1D7A8B9C0D1E2F3A4B5C6D", true},
+		{"empty", "", false},
+		{"garbage", "not-an-id", false},
+		{"short hex", "5f1d7a8b9c0d1e2f3a4b5c6", false},
+		{"non-hex object id", "5f1d7a8b9c0d1e2f3a4b5c6z", false},
+		{"float", "4.2", false},
+		{"path", "../etc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.id)
+			if tt.ok && err != nil {
+				t.Errorf("Validate(%q) = %v, want nil", tt.id, err)
+			}
+			if !tt.ok && kerrors.Code(err) != kerrors.ErrInvalidRequest {
+				t.Errorf("Validate(%q) = %v, want %s", tt.id, err, kerrors.ErrInvalidRequest)
+			}
+		})
+	}
+}
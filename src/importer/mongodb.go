@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const MongoDBContentType = "application/x-mongodb-ndjson"
+
+// ParseMongoDBJSON reads mongoexport output (one extended JSON document per
+// line) and converts the extended types to plain values: $oid to string,
+// $date to an RFC 3339 string, and the $number* wrappers to float64.
+func ParseMongoDBJSON(r io.Reader) ([]map[string]interface{}, error) {
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &doc); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		normalized, err := normalize(doc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		record, ok := normalized.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("line %d: document is not an object", line)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MongoDB export: %v", err)
+	}
+	return records, nil
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for k, inner := range v {
+				if strings.HasPrefix(k, "$") {
+					return normalizeExtended(k, inner)
+				}
+			}
+		}
+		for k, inner := range v {
+			n, err := normalize(inner)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			v[k] = n
+		}
+		return v, nil
+	case []interface{}:
+		for i, inner := range v {
+			n, err := normalize(inner)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = n
+		}
+		return v, nil
+	}
+	return v, nil
+}
+
+func normalizeExtended(op string, v interface{}) (interface{}, error) {
+	switch op {
+	case "$oid":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("$oid must be a string")
+		}
+		return s, nil
+	case "$numberLong", "$numberDouble", "$numberInt", "$numberDecimal":
+		return toFloat(op, v)
+	case "$date":
+		return normalizeDate(v)
+	}
+	// Not an extended type we know, e.g. a user field that starts with $.
+	n, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{op: n}, nil
+}
+
+func toFloat(op string, v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", op, v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("invalid %s value", op)
+}
+
+// normalizeDate accepts the relaxed form ({"$date": "2024-01-02T..."}) and
+// the canonical form ({"$date": {"$numberLong": "<millis>"}}).
+func normalizeDate(v interface{}) (string, error) {
+	var millis float64
+	switch v := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return "", fmt.Errorf("invalid $date %q", v)
+		}
+		return t.UTC().Format(time.RFC3339Nano), nil
+	case float64:
+		millis = v
+	case map[string]interface{}:
+		raw, ok := v["$numberLong"]
+		if !ok {
+			return "", fmt.Errorf("invalid $date value")
+		}
+		f, err := toFloat("$numberLong", raw)
+		if err != nil {
+			return "", err
+		}
+		millis = f
+	default:
+		return "", fmt.Errorf("invalid $date value")
+	}
+	return time.UnixMilli(int64(millis)).UTC().Format(time.RFC3339Nano), nil
+}
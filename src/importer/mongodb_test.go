@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMongoDBJSON(t *testing.T) {
+	export := `{"_id":{"$oid":"5f1d7a8b9c0d1e2f3a4b5c6d"},"name":"ann","visits":{"$numberLong":"9007199254740"},"score":{"$numberDouble":"4.5"},"age":{"$numberInt":"41"},"joined":{"$date":{"$numberLong":"1704164645000"}}}
+
+{"_id":{"$oid":"5f1d7a8b9c0d1e2f3a4b5c6e"},"seen":{"$date":"2024-01-02T03:04:05+01:00"},"tags":[{"$numberInt":"1"},"two"],"address":{"city":"Oslo","zip":{"$numberInt":"150"}},"$custom":true}
+`
+	records, err := ParseMongoDBJSON(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{
+			"_id":    "5f1d7a8b9c0d1e2f3a4b5c6d",
+			"name":   "ann",
+			"visits": float64(9007199254740),
+			"score":  4.5,
+			"age":    float64(41),
+			"joined": "2024-01-02T03:04:05Z",
+		},
+		{
+			"_id":     "5f1d7a8b9c0d1e2f3a4b5c6e",
+			"seen":    "2024-01-02T02:04:05Z",
+			"tags":    []interface{}{float64(1), "two"},
+			"address": map[string]interface{}{"city": "Oslo", "zip": float64(150)},
+			"$custom": true,
+		},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ParseMongoDBJSON =\n%#v\nwant\n%#v", records, want)
+	}
+}
+
+func TestParseMongoDBJSONErrors(t *testing.T) {
+	for _, export := range []string{
+		`{"_id":{"$oid":42}}`,
+		`{"n":{"$numberLong":"lots"}}`,
+		`{"at":{"$date":"yesterday"}}`,
+		`{"_id":`,
+		`[1,2]`,
+	} {
+		if _, err := ParseMongoDBJSON(strings.NewReader(export)); err == nil {
+			t.Errorf("ParseMongoDBJSON(%s) succeeded, want an error", export)
+		}
+	}
+}
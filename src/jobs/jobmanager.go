@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"kite/src/types"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+
+	// MaxJobs is how many jobs are kept; the oldest finished jobs are
+	// dropped first.
+	MaxJobs = 100
+)
+
+type job struct {
+	mu      sync.Mutex
+	status  types.JobStatus
+	started time.Time
+}
+
+func (j *job) snapshot() types.JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+type jobKey struct{}
+
+// JobManager runs functions in the background and keeps their status for
+// polling.
+type JobManager struct {
+	jobs sync.Map // job ID -> *job
+}
+
+func NewJobManager() *JobManager {
+	return &JobManager{}
+}
+
+// Submit starts fn in a new goroutine on behalf of owner and returns the job
+// ID. fn can report progress with Progress on the context it is given.
+func (m *JobManager) Submit(owner string, fn func(ctx context.Context) error) string {
+	now := time.Now().UTC()
+	j := &job{
+		started: now,
+		status: types.JobStatus{
+			JobID:     uuid.New().String(),
+			Owner:     owner,
+			Status:    StatusRunning,
+			StartedAt: now.Format(time.RFC3339),
+		},
+	}
+	m.jobs.Store(j.status.JobID, j)
+	m.prune()
+
+	go func() {
+		err := fn(context.WithValue(context.Background(), jobKey{}, j))
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.status.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+		if err != nil {
+			j.status.Status = StatusFailed
+			j.status.Error = err.Error()
+			return
+		}
+		j.status.Status = StatusDone
+		j.status.ProgressPercent = 100
+	}()
+	return j.status.JobID
+}
+
+// Status returns the job's current status and whether the job is known.
+func (m *JobManager) Status(id string) (types.JobStatus, bool) {
+	v, ok := m.jobs.Load(id)
+	if !ok {
+		return types.JobStatus{}, false
+	}
+	return v.(*job).snapshot(), true
+}
+
+// List returns the kept jobs submitted by owner, or all of them when owner is
+// empty, newest first.
+func (m *JobManager) List(owner string) []types.JobStatus {
+	all := m.sorted()
+	statuses := make([]types.JobStatus, 0, len(all))
+	for _, j := range all {
+		if status := j.snapshot(); owner == "" || status.Owner == owner {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+func (m *JobManager) sorted() []*job {
+	var all []*job
+	m.jobs.Range(func(_, v interface{}) bool {
+		all = append(all, v.(*job))
+		return true
+	})
+	sort.Slice(all, func(i, k int) bool { return all[i].started.After(all[k].started) })
+	return all
+}
+
+// prune drops the oldest finished jobs beyond MaxJobs. Running jobs are
+// always kept so they can still be polled.
+func (m *JobManager) prune() {
+	all := m.sorted()
+	for i := len(all) - 1; i >= 0 && len(all) > MaxJobs; i-- {
+		if all[i].snapshot().Status == StatusRunning {
+			continue
+		}
+		m.jobs.Delete(all[i].snapshot().JobID)
+		all = append(all[:i], all[i+1:]...)
+	}
+}
+
+// Progress records how far the job running under ctx has got. It does nothing
+// outside a job.
+func Progress(ctx context.Context, processed, inserted, total int) {
+	j, ok := ctx.Value(jobKey{}).(*job)
+	if !ok {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Inserted = inserted
+	j.status.Total = total
+	if total > 0 {
+		j.status.ProgressPercent = processed * 100 / total
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kite/src/types"
+)
+
+// wait polls a job until it is no longer running.
+func wait(t *testing.T, m *JobManager, id string) types.JobStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, ok := m.Status(id)
+		if !ok {
+			t.Fatalf("job %s is unknown", id)
+		}
+		if status.Status != StatusRunning {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still running", id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobManager(t *testing.T) {
+	m := NewJobManager()
+	release := make(chan struct{})
+	done := m.Submit("apikey:alice", func(ctx context.Context) error {
+		Progress(ctx, 5, 4, 10)
+		<-release
+		Progress(ctx, 10, 9, 10)
+		return nil
+	})
+	failed := m.Submit("apikey:bob", func(ctx context.Context) error {
+		return errors.New("bad row")
+	})
+
+	if status, _ := m.Status(done); status.Status != StatusRunning || status.Owner != "apikey:alice" {
+		t.Errorf("new job = %+v, want running for apikey:alice", status)
+	}
+	close(release)
+	if status := wait(t, m, done); status.Status != StatusDone || status.Inserted != 9 || status.Total != 10 || status.ProgressPercent != 100 || status.FinishedAt == "" {
+		t.Errorf("finished job = %+v", status)
+	}
+	if status := wait(t, m, failed); status.Status != StatusFailed || status.Error != "bad row" {
+		t.Errorf("failed job = %+v, want failed with its error", status)
+	}
+
+	if list := m.List("apikey:alice"); len(list) != 1 || list[0].JobID != done {
+		t.Errorf("alice's jobs = %+v", list)
+	}
+	if list := m.List(""); len(list) != 2 {
+		t.Errorf("all jobs = %d, want 2", len(list))
+	}
+	if _, ok := m.Status("unknown"); ok {
+		t.Error("Status found an unknown job")
+	}
+}
+
+func TestJobManagerKeepsRecentJobs(t *testing.T) {
+	m := NewJobManager()
+	for i := 0; i < MaxJobs+10; i++ {
+		wait(t, m, m.Submit("", func(ctx context.Context) error { return nil }))
+	}
+	if n := len(m.List("")); n != MaxJobs {
+		t.Errorf("kept %d jobs, want %d", n, MaxJobs)
+	}
+}
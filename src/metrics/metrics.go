@@ -0,0 +1,114 @@
+// Package metrics exposes server metrics in the Prometheus format. Write
+// queue gauges are read at scrape time; operation and request metrics are
+// only collected after Enable.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kite/src/writequeue"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+	enabled  atomic.Bool
+	once     sync.Once
+
+	operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kite_operations_total",
+		Help: "Controller operations by operation, schema and collection.",
+	}, []string{"operation", "schema", "collection"})
+	operationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kite_operation_errors_total",
+		Help: "Controller operations that returned an error.",
+	}, []string{"operation", "schema", "collection"})
+	operationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kite_operation_duration_seconds",
+		Help:    "Latency of controller operations.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "schema", "collection"})
+
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kite_http_requests_total",
+		Help: "API requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kite_http_request_duration_seconds",
+		Help:    "Latency of API requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func init() {
+	registry.MustRegister(queueCollector{})
+}
+
+// Enable starts collecting operation and request metrics.
+func Enable() {
+	once.Do(func() {
+		registry.MustRegister(operations, operationErrors, operationDuration, requests, requestDuration)
+	})
+	enabled.Store(true)
+}
+
+// ObserveOperation records one controller operation that began at start.
+func ObserveOperation(operation, schemaName, collectionName string, start time.Time, err error) {
+	if !enabled.Load() {
+		return
+	}
+	operations.WithLabelValues(operation, schemaName, collectionName).Inc()
+	if err != nil {
+		operationErrors.WithLabelValues(operation, schemaName, collectionName).Inc()
+	}
+	operationDuration.WithLabelValues(operation, schemaName, collectionName).Observe(time.Since(start).Seconds())
+}
+
+// ObserveRequest records one API request. route is the matched route
+// pattern, so record IDs do not become label values.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	if !enabled.Load() {
+		return
+	}
+	if route == "" {
+		route = "unmatched"
+	}
+	requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	requestDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+var (
+	queueDepthDesc = prometheus.NewDesc("kite_queue_depth",
+		"Writes waiting in a collection's write queue.", []string{"collection", "schema"}, nil)
+	queueOldestDesc = prometheus.NewDesc("kite_queue_oldest_ms",
+		"Age of the oldest queued write in milliseconds.", []string{"collection", "schema"}, nil)
+)
+
+// queueCollector reports the write queues as they are at scrape time.
+type queueCollector struct{}
+
+func (queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueDepthDesc
+	ch <- queueOldestDesc
+}
+
+func (queueCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, key := range writequeue.Keys() {
+		schemaName, collectionName, _ := strings.Cut(key, "/")
+		status := writequeue.QueueStatus(key)
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(status.PendingWrites), collectionName, schemaName)
+		ch <- prometheus.MustNewConstMetric(queueOldestDesc, prometheus.GaugeValue, float64(status.OldestPendingMs), collectionName, schemaName)
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"kite/src/controller"
+	kerrors "kite/src/errors"
+	"kite/src/response"
+	"kite/src/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requiredPermission maps a collection request onto the ACL permission it
+// needs.
+func requiredPermission(c *gin.Context) string {
+	if strings.HasSuffix(c.FullPath(), "/:collection_name/lock") {
+		return controller.PermLock
+	}
+	// Subscribing only leads to reads of the collection.
+	if strings.HasSuffix(c.FullPath(), "/:collection_name/subscribe") {
+		return controller.PermRead
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		return controller.PermRead
+	case http.MethodDelete:
+		if c.Param("id") == "" {
+			return controller.PermDrop
+		}
+		return controller.PermDelete
+	}
+	return controller.PermWrite
+}
+
+// ACL enforces the schema and collection access control lists on routes
+// that name a collection, and the schema ACL on routes that only name a
+// schema. identify returns the caller's identity.
+func ACL(identify func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schemaName, collectionName := c.Param("schema_name"), c.Param("collection_name")
+		switch {
+		case collectionName != "":
+			if !Authorize(c, identify(c), schemaName, collectionName, requiredPermission(c)) {
+				return
+			}
+		case schemaName != "":
+			if !AuthorizeSchema(c, identify(c), schemaName, requiredPermission(c)) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// Authorize checks that identity holds permission on a collection, and that
+// a logged-in user may use its schema, and aborts with 403 when not. A scoped
+// token decides on its own: it opens its collection and nothing else.
+// Handlers that reach collections other than the one in their route call it
+// for each of them.
+func Authorize(c *gin.Context, identity, schemaName, collectionName, permission string) bool {
+	if claims, ok := c.Get("token_claims"); ok {
+		return allowToken(c, claims.(*token.Claims), schemaName, collectionName, permission)
+	}
+	if !AllowSchema(c, schemaName) {
+		return false
+	}
+	allowed, denied, err := controller.CheckAccess(collectionName, schemaName, identity, permission)
+	if err != nil {
+		response.Abort(c, http.StatusInternalServerError, kerrors.Code(err), err.Error(), nil)
+		return false
+	}
+	if !allowed {
+		target := "collection " + collectionName
+		if denied == "schema" {
+			target = "schema " + schemaName
+		}
+		response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "missing "+permission+" permission on "+target, nil)
+		return false
+	}
+	return true
+}
+
+// AuthorizeSchema is Authorize for the schema ACL alone.
+func AuthorizeSchema(c *gin.Context, identity, schemaName, permission string) bool {
+	if claims, ok := c.Get("token_claims"); ok {
+		scoped := claims.(*token.Claims)
+		response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "token is only valid for collection "+scoped.Schema+"/"+scoped.Collection, nil)
+		return false
+	}
+	if !AllowSchema(c, schemaName) {
+		return false
+	}
+	allowed, err := controller.CheckSchemaACL(schemaName, identity, permission)
+	if err != nil {
+		response.Abort(c, http.StatusInternalServerError, kerrors.Code(err), err.Error(), nil)
+		return false
+	}
+	if !allowed {
+		response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "missing "+permission+" permission on schema "+schemaName, nil)
+		return false
+	}
+	return true
+}
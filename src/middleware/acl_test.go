@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kite/src/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequiredPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var got string
+	record := func(c *gin.Context) { got = requiredPermission(c) }
+	r.GET("/:schema_name/:collection_name", record)
+	r.HEAD("/:schema_name/:collection_name", record)
+	r.POST("/:schema_name/:collection_name", record)
+	r.DELETE("/:schema_name/:collection_name", record)
+	r.PUT("/:schema_name/:collection_name/:id", record)
+	r.PATCH("/:schema_name/:collection_name/:id", record)
+	r.DELETE("/:schema_name/:collection_name/:id", record)
+	r.POST("/:schema_name/:collection_name/lock", record)
+	r.POST("/:schema_name/:collection_name/subscribe", record)
+	r.DELETE("/:schema_name/:collection_name/lock", record)
+
+	for _, tc := range []struct {
+		method, path, want string
+	}{
+		{http.MethodGet, "/public/notes", controller.PermRead},
+		{http.MethodHead, "/public/notes", controller.PermRead},
+		{http.MethodPost, "/public/notes", controller.PermWrite},
+		{http.MethodDelete, "/public/notes", controller.PermDrop},
+		{http.MethodPut, "/public/notes/1", controller.PermWrite},
+		{http.MethodPatch, "/public/notes/1", controller.PermWrite},
+		{http.MethodDelete, "/public/notes/1", controller.PermDelete},
+		{http.MethodPost, "/public/notes/lock", controller.PermLock},
+		{http.MethodPost, "/public/notes/subscribe", controller.PermRead},
+		{http.MethodDelete, "/public/notes/lock", controller.PermLock},
+	} {
+		got = ""
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+		if got != tc.want {
+			t.Errorf("%s %s needs %q, want %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
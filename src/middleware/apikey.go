@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	kconfig "kite/src/config"
+	kerrors "kite/src/errors"
+	"kite/src/response"
+	"kite/src/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAuth rejects requests whose X-API-Key is not one of keys or the
+// admin key. The label of the matching key is stored as "api_key_label".
+// With neither keys nor an admin key configured every request passes, as do
+// public reads and requests carrying a scoped token.
+func APIKeyAuth(keys []types.APIKey, adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if (len(keys) == 0 && adminKey == "") || authenticated(c) {
+			c.Next()
+			return
+		}
+
+		presented := c.GetHeader("X-API-Key")
+		if presented != "" {
+			if adminKey != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(adminKey)) == 1 {
+				c.Set("api_key_label", "admin")
+				c.Next()
+				return
+			}
+			hash := kconfig.HashAPIKey(presented)
+			for _, key := range keys {
+				if subtle.ConstantTimeCompare([]byte(hash), []byte(key.Hash)) == 1 {
+					c.Set("api_key_label", key.Label)
+					c.Next()
+					return
+				}
+			}
+		}
+		response.Abort(c, http.StatusUnauthorized, kerrors.ErrUnauthorized, "unauthorized", nil)
+	}
+}
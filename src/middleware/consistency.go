@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"kite/src/controller"
+	kerrors "kite/src/errors"
+	"kite/src/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsistencyMiddleware reads the X-Kite-Consistency header into the
+// "consistency" context value, overriding the configured level for the
+// request.
+func ConsistencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch level := c.GetHeader("X-Kite-Consistency"); level {
+		case "":
+		case controller.ConsistencyStrong, controller.ConsistencyEventual:
+			c.Set("consistency", level)
+		default:
+			response.Abort(c, http.StatusBadRequest, kerrors.ErrInvalidRequest, "X-Kite-Consistency must be strong or eventual", nil)
+			return
+		}
+		c.Next()
+	}
+}
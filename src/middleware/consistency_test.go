@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConsistencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ConsistencyMiddleware())
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString("consistency")) })
+
+	tests := []struct {
+		header string
+		code   int
+		body   string
+	}{
+		{"", http.StatusOK, ""},
+		{"strong", http.StatusOK, "strong"},
+		{"eventual", http.StatusOK, "eventual"},
+		{"sometimes", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("X-Kite-Consistency", tt.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("X-Kite-Consistency %q = %d, want %d", tt.header, w.Code, tt.code)
+		} else if tt.code == http.StatusOK && w.Body.String() != tt.body {
+			t.Errorf("X-Kite-Consistency %q set %q, want %q", tt.header, w.Body, tt.body)
+		}
+	}
+}
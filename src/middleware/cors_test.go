@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSPreflightAllowsKiteHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORS([]string{"https://app.example"}, nil, nil, "/v1"))
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/public/notes", nil)
+	req.Header.Set("Origin", "https://app.example")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("preflight = %d, want 204", w.Code)
+	}
+	allowed := w.Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"X-Kite-Connection", "X-Kite-Consistency", "X-API-Key", "Authorization"} {
+		if !strings.Contains(allowed, header) {
+			t.Errorf("Access-Control-Allow-Headers %q lacks %s", allowed, header)
+		}
+	}
+	if strings.Contains(allowed, "X-Session-ID") {
+		t.Errorf("Access-Control-Allow-Headers %q still allows X-Session-ID", allowed)
+	}
+
+	req.Header.Set("Origin", "https://evil.example")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("preflight from another origin = %d, want 403", w.Code)
+	}
+}
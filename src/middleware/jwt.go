@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	kerrors "kite/src/errors"
+	"kite/src/response"
+	"kite/src/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireJWT demands a login token, sent as "Authorization: Bearer
+// <token>", and stores its claims as "jwt_claims". Non-admin users may only
+// use their own schema, and readonly users only GET. With no secret every
+// request passes, as do public reads and requests carrying a scoped token.
+//
+// Only the schema in the route is checked here; handlers that take schemas
+// from the request body check them with AllowSchema.
+func RequireJWT(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" || authenticated(c) {
+			c.Next()
+			return
+		}
+
+		bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			response.Abort(c, http.StatusUnauthorized, kerrors.ErrUnauthorized, "unauthorized", nil)
+			return
+		}
+		claims, err := token.ValidateSession(secret, strings.TrimSpace(bearer))
+		if err != nil {
+			response.Abort(c, http.StatusUnauthorized, kerrors.Code(err), err.Error(), nil)
+			return
+		}
+
+		if claims.Role == token.RoleReadOnly && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "user "+claims.Subject+" is read-only", nil)
+			return
+		}
+		c.Set("jwt_claims", claims)
+		if schemaName := c.Param("schema_name"); schemaName != "" && !AllowSchema(c, schemaName) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// AllowSchema reports whether the logged-in user of the request may use
+// schemaName, aborting with 403 when not. Requests without a login token
+// are left to the other checks.
+func AllowSchema(c *gin.Context, schemaName string) bool {
+	value, ok := c.Get("jwt_claims")
+	if !ok {
+		return true
+	}
+	claims := value.(*token.SessionClaims)
+	if claims.Role == token.RoleAdmin || schemaName == claims.SchemaName {
+		return true
+	}
+	response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "user "+claims.Subject+" may not access schema "+schemaName, nil)
+	return false
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"kite/src/cluster"
+	kerrors "kite/src/errors"
+	"kite/src/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LeaderOnly refuses writes with 503 on an instance that is not the cluster
+// leader, pointing the client at the leader instead. Reads, cluster routes
+// and multi requests, whose sub-requests are checked on their own, always
+// pass. A nil elector means clustering is off.
+func LeaderOnly(elector *cluster.Elector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if elector == nil || elector.IsLeader() {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		path := c.FullPath()
+		if strings.Contains(path, "/cluster/") || strings.HasSuffix(path, "/multi") {
+			c.Next()
+			return
+		}
+
+		details := gin.H{}
+		if info, err := elector.Leader(); err == nil {
+			details["leader_url"] = info.URL
+		}
+		response.Abort(c, http.StatusServiceUnavailable, kerrors.ErrNotLeader, "not leader", details)
+	}
+}
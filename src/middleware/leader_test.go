@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kite/src/cluster"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLeaderOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	leader := cluster.NewElector(dir, "http://primary:4141")
+	follower := cluster.NewElector(dir, "http://replica:4141")
+	if won, err := leader.Elect(); err != nil || !won {
+		t.Fatalf("election = %v, %v", won, err)
+	}
+	if won, err := follower.Elect(); err != nil || won {
+		t.Fatalf("follower election = %v, %v; want a loss", won, err)
+	}
+
+	router := func(elector *cluster.Elector) *gin.Engine {
+		r := gin.New()
+		r.Use(LeaderOnly(elector))
+		r.Any("/v1/:schema_name/:collection_name", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+	tests := []struct {
+		name    string
+		elector *cluster.Elector
+		method  string
+		want    int
+	}{
+		{"clustering off", nil, http.MethodPost, http.StatusOK},
+		{"leader write", leader, http.MethodPost, http.StatusOK},
+		{"follower read", follower, http.MethodGet, http.StatusOK},
+		{"follower write", follower, http.MethodPost, http.StatusServiceUnavailable},
+		{"follower delete", follower, http.MethodDelete, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router(tt.elector).ServeHTTP(w, httptest.NewRequest(tt.method, "/v1/public/notes", nil))
+		if w.Code != tt.want {
+			t.Errorf("%s = %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+			continue
+		}
+		if tt.want != http.StatusServiceUnavailable {
+			continue
+		}
+		var body struct {
+			Error   string `json:"error"`
+			Details struct {
+				LeaderURL string `json:"leader_url"`
+			} `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != "not leader" || body.Details.LeaderURL != "http://primary:4141" {
+			t.Errorf("%s body = %s, want not leader pointing at the primary", tt.name, w.Body)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"time"
+
+	"kite/src/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the status and latency of every request it wraps.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"kite/src/controller"
+	kerrors "kite/src/errors"
+	"kite/src/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Names rejects requests whose schema or collection name, as returned by
+// lookup, is not a plain file name, or whose schema is reserved, such as the
+// _system schema holding the token key and blocklist. Gin does not clean
+// ".." out of route parameters, so routes must not reach the controller
+// unchecked. It runs before the ACL, which would otherwise look up the
+// access lists of arbitrary paths.
+func Names(lookup func(*gin.Context, string) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checkNames(lookup(c, "schema_name"), lookup(c, "collection_name")); err != nil {
+			response.Abort(c, http.StatusBadRequest, kerrors.Code(err), err.Error(), nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+func checkNames(schemaName, collectionName string) error {
+	if schemaName != "" {
+		if err := controller.ValidateSchemaName(schemaName); err != nil {
+			return err
+		}
+	}
+	if collectionName != "" {
+		return controller.ValidateCollectionName(collectionName)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	kerrors "kite/src/errors"
+	"kite/src/response"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit rejects requests with 429 once the token bucket is empty and
+// tells the client how long to wait through Retry-After.
+func RateLimit(l *rate.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := l.Reserve()
+		if delay := r.Delay(); !r.OK() || delay > 0 {
+			r.Cancel()
+			setRateLimitHeaders(c, l)
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			response.Abort(c, http.StatusTooManyRequests, kerrors.ErrRateLimited, "rate limit exceeded", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitHeaders reports the bucket state on every response so clients can
+// throttle themselves before hitting a 429.
+func RateLimitHeaders(l *rate.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setRateLimitHeaders(c, l)
+		c.Next()
+	}
+}
+
+func setRateLimitHeaders(c *gin.Context, l *rate.Limiter) {
+	tokens := l.Tokens()
+	remaining := int(math.Floor(tokens))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	reset := time.Now()
+	if missing := float64(l.Burst()) - tokens; missing > 0 && l.Limit() > 0 {
+		reset = reset.Add(time.Duration(missing / float64(l.Limit()) * float64(time.Second)))
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(l.Burst()))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(reset.UnixNano())/1e9)), 10))
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := rate.NewLimiter(rate.Limit(0.01), 3)
+	r := gin.New()
+	r.Use(RateLimit(l), RateLimitHeaders(l))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	last := 4
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, w.Code)
+		}
+		remaining, err := strconv.Atoi(w.Header().Get("X-RateLimit-Remaining"))
+		if err != nil || remaining >= last {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want below %d", i+1, w.Header().Get("X-RateLimit-Remaining"), last)
+		}
+		last = remaining
+		if w.Header().Get("X-RateLimit-Limit") != "3" || w.Header().Get("X-RateLimit-Reset") == "" {
+			t.Errorf("request %d: limit headers = %v", i+1, w.Header())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit = %d, want 429", w.Code)
+	}
+	if retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retryAfter <= 0 {
+		t.Errorf("Retry-After = %q, want a positive number of seconds", w.Header().Get("Retry-After"))
+	}
+}
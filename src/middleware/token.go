@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	kerrors "kite/src/errors"
+	"kite/src/response"
+	"kite/src/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenValidationMiddleware accepts collection scoped tokens, sent as
+// "Authorization: Bearer <token>", as the credential for the collection they
+// were issued for, with the permissions they list. The claims are stored as
+// "token_claims", which lets the request past APIKeyAuth, RequireJWT and the
+// ACL. Requests without a scoped token, such as those with a login token,
+// pass through unchanged.
+func TokenValidationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		bearer = strings.TrimSpace(bearer)
+		if !ok || !token.IsScoped(bearer) {
+			c.Next()
+			return
+		}
+
+		claims, err := token.Validate(bearer)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if kerrors.Code(err) == kerrors.ErrUnauthorized {
+				status = http.StatusUnauthorized
+			}
+			response.Abort(c, status, kerrors.Code(err), err.Error(), nil)
+			return
+		}
+		c.Set("token_claims", claims)
+		if !allowToken(c, claims, c.Param("schema_name"), c.Param("collection_name"), requiredPermission(c)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowToken reports whether a scoped token grants permission on a
+// collection, aborting with 403 when not.
+func allowToken(c *gin.Context, claims *token.Claims, schemaName, collectionName, permission string) bool {
+	if claims.Schema != schemaName || claims.Collection != collectionName {
+		response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "token is only valid for collection "+claims.Schema+"/"+claims.Collection, nil)
+		return false
+	}
+	if !claims.Allows(permission) {
+		response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "token lacks "+permission+" permission on collection "+collectionName, nil)
+		return false
+	}
+	return true
+}
+
+// authenticated reports whether an earlier middleware already settled who
+// may make the request: a public read or a scoped token.
+func authenticated(c *gin.Context) bool {
+	_, scoped := c.Get("token_claims")
+	return scoped || c.GetBool("public_read")
+}
+
+// RequireAdminKey only lets through requests whose X-API-Key matches
+// adminKey. With no admin key configured every request is refused.
+func RequireAdminKey(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasAdminKey(c, adminKey) {
+			response.Abort(c, http.StatusForbidden, kerrors.ErrForbidden, "admin API key required", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the request carries adminKey, or a login token
+// for a user with the admin role.
+func IsAdmin(c *gin.Context, adminKey string) bool {
+	if hasAdminKey(c, adminKey) {
+		return true
+	}
+	claims, ok := c.Get("jwt_claims")
+	return ok && claims.(*token.SessionClaims).Role == token.RoleAdmin
+}
+
+func hasAdminKey(c *gin.Context, adminKey string) bool {
+	key := c.GetHeader("X-API-Key")
+	return adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1
+}
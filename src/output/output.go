@@ -0,0 +1,73 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"kite/src/types"
+)
+
+// Options controls how records are rendered by Write.
+type Options struct {
+	Format  string // "json" or "jsonl"
+	Compact bool
+	Indent  string
+}
+
+// DefaultIndent is used for pretty-printed JSON unless --indent is given.
+const DefaultIndent = "  "
+
+// ParseIndent turns an --indent value, either a number of spaces or "tab",
+// into the indent string passed to json.MarshalIndent.
+func ParseIndent(s string) (string, error) {
+	if s == "tab" || s == `\t` {
+		return "\t", nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 16 {
+		return "", fmt.Errorf("invalid indent %q, expected 0-16 or tab", s)
+	}
+	return strings.Repeat(" ", n), nil
+}
+
+// Write renders records to w according to opts.
+func Write(records []types.Record, w io.Writer, opts Options) error {
+	switch opts.Format {
+	case "jsonl":
+		return WriteJSONL(records, w)
+	case "", "json":
+		if records == nil {
+			records = []types.Record{}
+		}
+		var data []byte
+		var err error
+		if opts.Compact {
+			data, err = json.Marshal(records)
+		} else {
+			data, err = json.MarshalIndent(records, "", opts.Indent)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON data: %v", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	default:
+		return fmt.Errorf("unknown format %q, expected json or jsonl", opts.Format)
+	}
+}
+
+// WriteJSONL writes one compact JSON object per line so the output can be
+// streamed through tools such as jq.
+func WriteJSONL(records []types.Record, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write record: %v", err)
+		}
+	}
+	return nil
+}
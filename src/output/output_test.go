@@ -0,0 +1,95 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestWriteJSONL(t *testing.T) {
+	records := []types.Record{
+		{"_id": "1", "name": "ann", "tags": []interface{}{"a", "b"}},
+		{"_id": "2", "html": "<b>&</b>"},
+		{"_id": "3", "nested": map[string]interface{}{"n": 1.0}, "x": nil, "y": true},
+	}
+	var buf bytes.Buffer
+	if err := Write(records, &buf, Options{Format: "jsonl"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `\u003c`) {
+		t.Errorf("JSONL escapes HTML: %s", buf.String())
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(records) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(records), buf.String())
+	}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v: %s", i, err, line)
+		}
+		if len(record) != len(records[i]) || record["_id"] != records[i]["_id"] {
+			t.Errorf("line %d = %v, want %v", i, record, records[i])
+		}
+	}
+
+	buf.Reset()
+	if err := WriteJSONL(nil, &buf); err != nil || buf.Len() != 0 {
+		t.Errorf("no records wrote %q, %v; want nothing", buf.String(), err)
+	}
+	if err := Write(records, &buf, Options{Format: "yaml"}); err == nil {
+		t.Error("Write accepted an unknown format")
+	}
+}
+
+func TestWriteCompactAndIndented(t *testing.T) {
+	records := []types.Record{{"_id": "1", "name": "ann"}, {"_id": "2", "name": "bob"}}
+
+	var buf bytes.Buffer
+	if err := Write(records, &buf, Options{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+	out := strings.TrimSuffix(buf.String(), "\n")
+	if strings.ContainsAny(out, "\n ") {
+		t.Errorf("compact output has whitespace: %q", buf.String())
+	}
+	var parsed []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil || len(parsed) != 2 {
+		t.Errorf("compact output does not parse back: %v: %s", err, out)
+	}
+
+	indent, err := ParseIndent("4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := Write(records, &buf, Options{Indent: indent}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\n    {\n        \"_id\": \"1\",") {
+		t.Errorf("--indent 4 output is not indented by 4 spaces:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Write(nil, &buf, Options{Compact: true}); err != nil || buf.String() != "[]\n" {
+		t.Errorf("no records = %q, %v; want []", buf.String(), err)
+	}
+}
+
+func TestParseIndent(t *testing.T) {
+	tests := map[string]string{"tab": "\t", `\t`: "\t", "0": "", "4": "    "}
+	for in, want := range tests {
+		if got, err := ParseIndent(in); err != nil || got != want {
+			t.Errorf("ParseIndent(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"-1", "17", "two"} {
+		if _, err := ParseIndent(in); err == nil {
+			t.Errorf("ParseIndent(%q) succeeded, want an error", in)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package pidfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kite.pid")
+	if err := Write(path, os.Getpid()); err != nil {
+		t.Fatal(err)
+	}
+	pid, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid != os.Getpid() {
+		t.Errorf("Read = %d, want %d", pid, os.Getpid())
+	}
+	if !IsRunning(pid) {
+		t.Error("IsRunning reports the test process as stopped")
+	}
+	if IsRunning(1 << 30) {
+		t.Error("IsRunning reports a pid beyond pid_max as running")
+	}
+
+	if err := Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("pid file still exists after Remove: %v", err)
+	}
+	if err := Remove(path); err != nil {
+		t.Errorf("removing a missing pid file = %v, want nil", err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Read of a missing pid file succeeded")
+	}
+}
+
+func TestReadRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kite.pid")
+	for _, content := range []string{"", "kite\n", "-1\n", "0"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if pid, err := Read(path); err == nil {
+			t.Errorf("Read(%q) = %d, want an error", content, pid)
+		}
+	}
+}
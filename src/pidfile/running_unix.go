@@ -0,0 +1,11 @@
+//go:build unix
+
+package pidfile
+
+import "syscall"
+
+// IsRunning reports whether a process with pid exists.
+func IsRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
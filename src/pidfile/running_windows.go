@@ -0,0 +1,22 @@
+//go:build windows
+
+package pidfile
+
+import "golang.org/x/sys/windows"
+
+const stillActive = 259
+
+// IsRunning reports whether a process with pid exists.
+func IsRunning(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
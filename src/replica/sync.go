@@ -0,0 +1,212 @@
+package replica
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MarkerFile marks a directory as a replica. Sync writes it on the first
+// sync into a new or empty directory and refuses to touch a non-empty
+// directory without it, so a mistyped --dst cannot be pruned away.
+const MarkerFile = ".kite-replica"
+
+// Sync makes dst a copy of src, copying files whose size or modification
+// time differ and removing files that no longer exist in src. It returns the
+// number of files copied. dst must not overlap src, and must be new, empty
+// or already carry MarkerFile.
+func Sync(src, dst string) (int, error) {
+	if err := checkOverlap(src, dst); err != nil {
+		return 0, err
+	}
+	if err := claimReplica(dst); err != nil {
+		return 0, err
+	}
+
+	copied := 0
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if existing, err := os.Stat(target); err == nil &&
+			existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+			return nil
+		}
+		if err := copyFile(path, target, info); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		return copied, fmt.Errorf("failed to sync %s to %s: %v", src, dst, err)
+	}
+
+	if err := prune(src, dst); err != nil {
+		return copied, err
+	}
+	return copied, nil
+}
+
+// checkOverlap rejects a dst that is src or lies inside it (or the other way
+// round), where copying would recurse and pruning would delete primary data.
+func checkOverlap(src, dst string) error {
+	absSrc, err := resolve(src)
+	if err != nil {
+		return fmt.Errorf("invalid source directory: %v", err)
+	}
+	absDst, err := resolve(dst)
+	if err != nil {
+		return fmt.Errorf("invalid replica directory: %v", err)
+	}
+	if within(absDst, absSrc) || within(absSrc, absDst) {
+		return fmt.Errorf("replica directory %s overlaps source directory %s", dst, src)
+	}
+	return nil
+}
+
+// resolve returns the absolute path of dir with symlinks followed as far as
+// the path exists, so that a link cannot disguise an overlap.
+func resolve(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	for path := abs; ; path = filepath.Dir(path) {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				real = filepath.Join(real, missing[i])
+			}
+			return real, nil
+		}
+		if filepath.Dir(path) == path {
+			return abs, nil
+		}
+		missing = append(missing, filepath.Base(path))
+	}
+}
+
+// within reports whether path is dir or lies below it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// claimReplica creates dst if needed and writes MarkerFile into it, refusing
+// a non-empty directory that is not already a replica.
+func claimReplica(dst string) error {
+	marker := filepath.Join(dst, MarkerFile)
+	if _, err := os.Stat(marker); err == nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dst)
+	switch {
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(dst, 0700); err != nil {
+			return fmt.Errorf("failed to create replica directory: %v", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to read replica directory: %v", err)
+	case len(entries) > 0:
+		return fmt.Errorf("refusing to sync into %s: directory is not empty and has no %s marker", dst, MarkerFile)
+	}
+	if err := os.WriteFile(marker, nil, 0600); err != nil {
+		return fmt.Errorf("failed to mark replica directory: %v", err)
+	}
+	return nil
+}
+
+// SyncRsync mirrors src into dst with rsync, for deployments where the
+// replica lives on another host or rsync is otherwise preferred.
+func SyncRsync(src, dst string) error {
+	cmd := exec.Command("rsync", "-a", "--delete", "--exclude=/"+MarkerFile, filepath.Clean(src)+string(filepath.Separator), dst)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("rsync failed: %v", err)
+	}
+	return nil
+}
+
+// copyFile writes through a temp file so readers of the replica never see a
+// partially copied collection.
+func copyFile(src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".sync-tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// prune removes files and directories from dst that are not present in src,
+// keeping the replica marker.
+func prune(src, dst string) error {
+	var stale []string
+	err := filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dst, path)
+		if err != nil || rel == "." || rel == MarkerFile {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(src, rel)); os.IsNotExist(err) {
+			stale = append(stale, path)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan replica: %v", err)
+	}
+	for _, path := range stale {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s from replica: %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package replica
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncCopiesAndPrunes(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "replica")
+	writeTestFile(t, filepath.Join(src, "default", "users.json"), `[]`)
+
+	if _, err := Sync(src, dst); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "default", "users.json")); err != nil {
+		t.Fatalf("collection not copied: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(src, "default", "users.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(src, dst); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "default", "users.json")); !os.IsNotExist(err) {
+		t.Fatalf("stale collection not pruned: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, MarkerFile)); err != nil {
+		t.Fatalf("replica marker pruned: %v", err)
+	}
+}
+
+func TestSyncRefusesUnmarkedDirectory(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeTestFile(t, filepath.Join(src, "default", "users.json"), `[]`)
+	writeTestFile(t, filepath.Join(dst, "notes.txt"), "keep me")
+
+	_, err := Sync(src, dst)
+	if err == nil || !strings.Contains(err.Error(), MarkerFile) {
+		t.Fatalf("expected marker error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "notes.txt")); err != nil {
+		t.Fatalf("unrelated file removed: %v", err)
+	}
+}
+
+func TestSyncRefusesOverlap(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "db")
+	writeTestFile(t, filepath.Join(src, "default", "users.json"), `[]`)
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(src, link); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, dst := range map[string]string{
+		"same":    src,
+		"inside":  filepath.Join(src, "replica"),
+		"parent":  root,
+		"symlink": filepath.Join(link, "replica"),
+	} {
+		if _, err := Sync(src, dst); err == nil || !strings.Contains(err.Error(), "overlaps") {
+			t.Errorf("%s: expected overlap error, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "default", "users.json")); err != nil {
+		t.Fatalf("source collection removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "replica")); !os.IsNotExist(err) {
+		t.Fatalf("replica directory created inside source: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package response
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const versionKey = "api_version"
+
+// Version tags every request in a route group with its API version. Groups
+// tagged "v2" get enveloped responses; anything else keeps the v1 shapes.
+func Version(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(versionKey, version)
+		c.Next()
+	}
+}
+
+func enveloped(c *gin.Context) bool {
+	return c.GetString(versionKey) == "v2"
+}
+
+func meta(c *gin.Context) gin.H {
+	requestID := c.GetHeader("X-Request-ID")
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	return gin.H{"request_id": requestID, "timestamp": time.Now().UTC().Format(time.RFC3339)}
+}
+
+// OK writes a 200 response carrying data.
+func OK(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusOK, data)
+}
+
+// JSON writes a successful response with the given status.
+func JSON(c *gin.Context, status int, data interface{}) {
+	if !enveloped(c) {
+		c.JSON(status, data)
+		return
+	}
+	c.JSON(status, gin.H{"success": true, "data": data, "meta": meta(c)})
+}
+
+// Fail writes an error response. details is omitted when nil.
+func Fail(c *gin.Context, status int, code, msg string, details interface{}) {
+	if !enveloped(c) {
+		resp := gin.H{"error": msg, "code": code}
+		if details != nil {
+			resp["details"] = details
+		}
+		c.JSON(status, resp)
+		return
+	}
+
+	errBody := gin.H{"code": code, "message": msg}
+	if details != nil {
+		errBody["details"] = details
+	}
+	c.JSON(status, gin.H{"success": false, "error": errBody, "meta": meta(c)})
+}
+
+// Abort writes an error response with Fail and stops the handler chain.
+func Abort(c *gin.Context, status int, code, msg string, details interface{}) {
+	Fail(c, status, code, msg, details)
+	c.Abort()
+}
@@ -0,0 +1,113 @@
+package stale
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kite/src/controller"
+	"kite/src/types"
+)
+
+const DefaultThresholdDays = 90
+
+// LastAccess returns when a collection was last read or written. Collections
+// written before access tracking existed fall back to the data file's
+// modification time.
+func LastAccess(collectionName, schemaName string) (time.Time, error) {
+	meta, err := controller.ReadMeta(collectionName, schemaName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if meta.LastAccessedAt != "" {
+		t, err := time.Parse(time.RFC3339, meta.LastAccessedAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid last_accessed_at %q: %v", meta.LastAccessedAt, err)
+		}
+		return t, nil
+	}
+
+	info, err := os.Stat(filepath.Join(controller.CollectionPath(schemaName, collectionName, true), collectionName+".txt"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat collection file: %v", err)
+	}
+	return info.ModTime().UTC(), nil
+}
+
+// Find returns the collections in schemaName that have not been accessed
+// for more than thresholdDays.
+func Find(schemaName string, thresholdDays int) ([]types.StaleCollection, error) {
+	collections, err := controller.ListCollections(schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -thresholdDays)
+	stale := []types.StaleCollection{}
+	for _, collectionName := range collections {
+		lastAccess, err := LastAccess(collectionName, schemaName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping stale check for %s/%s: %v\n", schemaName, collectionName, err)
+			continue
+		}
+		if !lastAccess.Before(cutoff) {
+			continue
+		}
+		stale = append(stale, types.StaleCollection{
+			Schema:         schemaName,
+			Collection:     collectionName,
+			LastAccessedAt: lastAccess.Format(time.RFC3339),
+			DaysIdle:       int(now.Sub(lastAccess).Hours() / 24),
+		})
+	}
+	return stale, nil
+}
+
+// FindAll runs Find over the database root and every schema.
+func FindAll(thresholdDays int) ([]types.StaleCollection, error) {
+	schemas, err := controller.ListSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []types.StaleCollection
+	for _, schemaName := range append([]string{""}, schemas...) {
+		found, err := Find(schemaName, thresholdDays)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, found...)
+	}
+	return all, nil
+}
+
+// StartChecker logs a warning for every stale collection now and then once
+// per interval.
+func StartChecker(thresholdDays int, interval time.Duration) {
+	run := func() {
+		found, err := FindAll(thresholdDays)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Stale collection check failed: %v\n", err)
+		}
+		for _, s := range found {
+			slog.Warn("stale collection",
+				"schema", s.Schema,
+				"collection", s.Collection,
+				"last_accessed_at", s.LastAccessedAt,
+				"days_idle", s.DaysIdle,
+				"threshold_days", thresholdDays)
+		}
+	}
+
+	run()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
@@ -0,0 +1,50 @@
+package stale
+
+import (
+	"testing"
+	"time"
+
+	"kite/src/controller"
+	"kite/src/types"
+)
+
+func TestFindReportsIdleCollections(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64}
+	controller.Configure(cfg)
+	controller.NewStore(cfg)
+	if err := controller.EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"archive", "notes"} {
+		if err := controller.AddCollectionAt(name, "public", "", `[{"title":"a"}]`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idle := time.Now().AddDate(0, 0, -91).UTC().Format(time.RFC3339)
+	if err := controller.UpdateMeta("archive", "public", func(meta *types.CollectionMeta) { meta.LastAccessedAt = idle }); err != nil {
+		t.Fatal(err)
+	}
+	if err := controller.UpdateMeta("notes", "public", func(meta *types.CollectionMeta) {
+		meta.LastAccessedAt = time.Now().UTC().Format(time.RFC3339)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := Find("public", DefaultThresholdDays)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(found) != 1 || found[0].Collection != "archive" {
+		t.Fatalf("stale = %+v, want only archive", found)
+	}
+	if found[0].LastAccessedAt != idle || found[0].DaysIdle != 91 {
+		t.Errorf("archive = %+v, want last access %s and 91 days idle", found[0], idle)
+	}
+
+	if found, err := Find("public", 100); err != nil || len(found) != 0 {
+		t.Errorf("Find with a 100 day threshold = %+v, %v; want none", found, err)
+	}
+	if _, err := Find("missing", DefaultThresholdDays); err == nil {
+		t.Error("Find on a missing schema succeeded, want an error")
+	}
+}
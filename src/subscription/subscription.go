@@ -0,0 +1,93 @@
+package subscription
+
+import (
+	"sync"
+	"time"
+
+	"kite/src/types"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTTL is how long a subscription lives without being polled.
+const DefaultTTL = 5 * time.Minute
+
+type entry struct {
+	mu        sync.Mutex
+	sub       types.Subscription
+	expiresAt time.Time
+}
+
+// SubscriptionManager keeps polling subscriptions in memory. Each poll
+// extends a subscription by the TTL; one left unpolled for longer expires.
+type SubscriptionManager struct {
+	subs sync.Map // subscription ID -> *entry
+	ttl  time.Duration
+}
+
+func NewSubscriptionManager(ttl time.Duration) *SubscriptionManager {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &SubscriptionManager{ttl: ttl}
+}
+
+// Subscribe registers interest in a collection and drops any subscriptions
+// that have expired.
+func (m *SubscriptionManager) Subscribe(schemaName, collectionName string) types.Subscription {
+	now := time.Now().UTC()
+	m.sweep(now)
+
+	e := &entry{
+		expiresAt: now.Add(m.ttl),
+		sub: types.Subscription{
+			ID:         uuid.New().String(),
+			Schema:     schemaName,
+			Collection: collectionName,
+			CreatedAt:  now.Format(time.RFC3339),
+		},
+	}
+	e.sub.ExpiresAt = e.expiresAt.Format(time.RFC3339)
+	m.subs.Store(e.sub.ID, e)
+	return e.sub
+}
+
+// Poll returns the subscription and extends its lifetime. It reports false
+// for unknown and expired subscriptions.
+func (m *SubscriptionManager) Poll(id string) (types.Subscription, bool) {
+	v, ok := m.subs.Load(id)
+	if !ok {
+		return types.Subscription{}, false
+	}
+	e := v.(*entry)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now().UTC()
+	if now.After(e.expiresAt) {
+		m.subs.Delete(id)
+		return types.Subscription{}, false
+	}
+	e.expiresAt = now.Add(m.ttl)
+	e.sub.ExpiresAt = e.expiresAt.Format(time.RFC3339)
+	return e.sub, true
+}
+
+// Unsubscribe removes a subscription and reports whether it existed.
+func (m *SubscriptionManager) Unsubscribe(id string) bool {
+	_, ok := m.subs.LoadAndDelete(id)
+	return ok
+}
+
+func (m *SubscriptionManager) sweep(now time.Time) {
+	m.subs.Range(func(k, v interface{}) bool {
+		e := v.(*entry)
+		e.mu.Lock()
+		expired := now.After(e.expiresAt)
+		e.mu.Unlock()
+		if expired {
+			m.subs.Delete(k)
+		}
+		return true
+	})
+}
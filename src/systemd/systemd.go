@@ -0,0 +1,115 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"kite/src/types"
+)
+
+const (
+	DefaultServiceName = "kite"
+	DefaultDataDir     = "/var/lib/kite"
+)
+
+// UnitPath returns where the unit file for cfg is installed.
+func UnitPath(cfg types.SystemdConfig) (string, error) {
+	name := cfg.ServiceName + ".service"
+	if !cfg.UserUnit {
+		return filepath.Join("/etc/systemd/system", name), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %v", err)
+	}
+	return filepath.Join(home, ".config", "systemd", "user", name), nil
+}
+
+// GenerateUnit renders the unit file for cfg.
+func GenerateUnit(cfg types.SystemdConfig) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Kite database server\n")
+	b.WriteString("After=network.target\n\n")
+
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	// kite finds templates/ and static/ in its working directory, and its
+	// config file and database through the environment.
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", cfg.InstallDir)
+	fmt.Fprintf(&b, "Environment=\"KITE_CONFIG=%s\"\n", filepath.Join(cfg.DataDir, "config.json"))
+	fmt.Fprintf(&b, "Environment=\"KITE_DB_PATH=%s\"\n", filepath.Join(cfg.DataDir, "db"))
+	fmt.Fprintf(&b, "ExecStart=%s serve\n", cfg.BinaryPath)
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n")
+	if !cfg.UserUnit {
+		fmt.Fprintf(&b, "User=%s\n", cfg.User)
+		fmt.Fprintf(&b, "Group=%s\n", cfg.Group)
+	}
+	b.WriteString("ProtectSystem=strict\n")
+	fmt.Fprintf(&b, "ReadWritePaths=%s\n", cfg.DataDir)
+	b.WriteString("PrivateTmp=true\n\n")
+
+	b.WriteString("[Install]\n")
+	if cfg.UserUnit {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+	return b.String()
+}
+
+// Install writes the unit file and returns its path.
+func Install(cfg types.SystemdConfig) (string, error) {
+	path, err := UnitPath(cfg)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(GenerateUnit(cfg)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write unit file: %v", err)
+	}
+	return path, nil
+}
+
+// Uninstall removes the unit file and returns its path.
+func Uninstall(cfg types.SystemdConfig) (string, error) {
+	path, err := UnitPath(cfg)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove unit file: %v", err)
+	}
+	return path, nil
+}
+
+// Status runs systemctl status for the service, printing its output.
+func Status(cfg types.SystemdConfig) error {
+	args := []string{"status", cfg.ServiceName + ".service"}
+	if cfg.UserUnit {
+		args = append([]string{"--user"}, args...)
+	}
+	cmd := exec.Command("systemctl", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// systemctl returns the command prefix for cfg, for printing next steps.
+func systemctl(cfg types.SystemdConfig) string {
+	if cfg.UserUnit {
+		return "systemctl --user"
+	}
+	return "systemctl"
+}
+
+// NextSteps describes how to start the service after Install.
+func NextSteps(cfg types.SystemdConfig) string {
+	return fmt.Sprintf("%s daemon-reload && %s enable --now %s.service", systemctl(cfg), systemctl(cfg), cfg.ServiceName)
+}
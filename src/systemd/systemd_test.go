@@ -0,0 +1,31 @@
+package systemd
+
+import (
+	"strings"
+	"testing"
+
+	"kite/src/types"
+)
+
+func TestGenerateUnitPaths(t *testing.T) {
+	unit := GenerateUnit(types.SystemdConfig{
+		ServiceName: "kite",
+		BinaryPath:  "/opt/kite/kite",
+		DataDir:     "/var/lib/kite",
+		InstallDir:  "/opt/kite",
+		User:        "kite",
+		Group:       "kite",
+	})
+
+	for _, line := range []string{
+		"WorkingDirectory=/opt/kite",
+		`Environment="KITE_CONFIG=/var/lib/kite/config.json"`,
+		`Environment="KITE_DB_PATH=/var/lib/kite/db"`,
+		"ExecStart=/opt/kite/kite serve",
+		"ReadWritePaths=/var/lib/kite",
+	} {
+		if !strings.Contains(unit, line+"\n") {
+			t.Errorf("unit lacks %q:\n%s", line, unit)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package testutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"kite/src/controller"
+	"kite/src/types"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// AssertOptions controls how AssertCollectionEquals compares records.
+type AssertOptions struct {
+	// CompareMetadata also compares _id, createdAt, updatedAt, _version and
+	// lock fields; by default only data fields are compared.
+	CompareMetadata bool
+}
+
+func readCollection(t testing.TB, schemaName, collectionName string) []types.Record {
+	t.Helper()
+	records, err := controller.ReadCollection(collectionName, schemaName)
+	if err != nil {
+		t.Fatalf("failed to read collection %s/%s: %v", schemaName, collectionName, err)
+	}
+	return records
+}
+
+// normalize round-trips v through JSON so that expected values written as Go
+// literals (ints, typed slices) compare equal to decoded records.
+func normalize(t testing.TB, v interface{}) []map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal records: %v", err)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("failed to unmarshal records: %v", err)
+	}
+	if records == nil {
+		records = []map[string]interface{}{}
+	}
+	return records
+}
+
+// AssertCollectionEquals fails t with a -want +got diff unless the collection
+// holds exactly expected, in order.
+func AssertCollectionEquals(t testing.TB, schemaName, collectionName string, expected []map[string]interface{}, opts AssertOptions) {
+	t.Helper()
+	actual := normalize(t, readCollection(t, schemaName, collectionName))
+	want := normalize(t, expected)
+	if !opts.CompareMetadata {
+		for _, records := range [][]map[string]interface{}{actual, want} {
+			for i, record := range records {
+				records[i] = userFields(record)
+			}
+		}
+	}
+	if diff := cmp.Diff(want, actual); diff != "" {
+		t.Errorf("collection %s/%s does not match (-want +got):\n%s", schemaName, collectionName, diff)
+	}
+}
+
+// AssertRecordCount fails t unless the collection holds n records.
+func AssertRecordCount(t testing.TB, schemaName, collectionName string, n int) {
+	t.Helper()
+	if got := len(readCollection(t, schemaName, collectionName)); got != n {
+		t.Errorf("collection %s/%s has %d records, want %d", schemaName, collectionName, got, n)
+	}
+}
+
+// AssertRecordExists fails t unless the collection holds a record with _id id.
+func AssertRecordExists(t testing.TB, schemaName, collectionName, id string) {
+	t.Helper()
+	records := readCollection(t, schemaName, collectionName)
+	for _, record := range records {
+		if record["_id"] == id {
+			return
+		}
+	}
+	t.Errorf("collection %s/%s has no record with _id %s (%d records checked)", schemaName, collectionName, id, len(records))
+}
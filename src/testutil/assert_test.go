@@ -0,0 +1,122 @@
+package testutil
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"kite/src/controller"
+	"kite/src/types"
+)
+
+// recorder captures the failures an assertion reports instead of failing
+// the test running it.
+type recorder struct {
+	testing.TB
+	failures []string
+	fatal    bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+	runtime.Goexit()
+}
+
+// record runs assert against a recorder on its own goroutine, so that a
+// Fatalf can stop it.
+func record(t *testing.T, assert func(tb testing.TB)) *recorder {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert(r)
+	}()
+	<-done
+	return r
+}
+
+func TestAssertFailureMessages(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64}
+	controller.Configure(cfg)
+	if err := controller.NewStore(cfg).AddCollection("notes", "public", `[{"title":"a"},{"title":"b"}]`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		assert func(tb testing.TB)
+		want   []string
+		fatal  bool
+	}{
+		{
+			name: "collection matches",
+			assert: func(tb testing.TB) {
+				AssertCollectionEquals(tb, "public", "notes", []map[string]interface{}{{"title": "a"}, {"title": "b"}}, AssertOptions{})
+			},
+		},
+		{
+			name: "collection differs",
+			assert: func(tb testing.TB) {
+				AssertCollectionEquals(tb, "public", "notes", []map[string]interface{}{{"title": "a"}, {"title": "c"}}, AssertOptions{})
+			},
+			want: []string{"collection public/notes does not match (-want +got):", `-`, `"c"`, `+`, `"b"`},
+		},
+		{
+			name: "collection missing",
+			assert: func(tb testing.TB) {
+				AssertCollectionEquals(tb, "public", "missing", nil, AssertOptions{})
+			},
+			want:  []string{"failed to read collection public/missing:"},
+			fatal: true,
+		},
+		{
+			name:   "count matches",
+			assert: func(tb testing.TB) { AssertRecordCount(tb, "public", "notes", 2) },
+		},
+		{
+			name:   "count differs",
+			assert: func(tb testing.TB) { AssertRecordCount(tb, "public", "notes", 3) },
+			want:   []string{"collection public/notes has 2 records, want 3"},
+		},
+		{
+			name:   "record missing",
+			assert: func(tb testing.TB) { AssertRecordExists(tb, "public", "notes", "no-such-id") },
+			want:   []string{"collection public/notes has no record with _id no-such-id (2 records checked)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := record(t, tt.assert)
+			if r.fatal != tt.fatal {
+				t.Errorf("fatal = %v, want %v", r.fatal, tt.fatal)
+			}
+			if len(tt.want) == 0 {
+				if len(r.failures) > 0 {
+					t.Errorf("unexpected failures: %q", r.failures)
+				}
+				return
+			}
+			if len(r.failures) != 1 {
+				t.Fatalf("got %d failures, want 1: %q", len(r.failures), r.failures)
+			}
+			for _, part := range tt.want {
+				if !strings.Contains(r.failures[0], part) {
+					t.Errorf("failure %q does not contain %q", r.failures[0], part)
+				}
+			}
+		})
+	}
+
+	records := readCollection(t, "public", "notes")
+	if r := record(t, func(tb testing.TB) { AssertRecordExists(tb, "public", "notes", records[1]["_id"].(string)) }); len(r.failures) > 0 {
+		t.Errorf("AssertRecordExists failed for an existing record: %q", r.failures)
+	}
+}
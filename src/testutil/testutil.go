@@ -0,0 +1,252 @@
+// Package testutil provides an in-memory stand-in for the controller
+// package, for tests of code that depends on types.DBOperations.
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	kdiff "kite/src/diff"
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+	kid "kite/src/id"
+	"kite/src/types"
+)
+
+// FakeDB keeps collections in memory. It stamps the same metadata fields and
+// returns the same errors as the file-backed controller, but does not model
+// record locks, schemas, ACLs or history.
+type FakeDB struct {
+	mu          sync.Mutex
+	collections map[string][]types.Record
+}
+
+var _ types.DBOperations = (*FakeDB)(nil)
+
+func NewFakeDB() *FakeDB {
+	return &FakeDB{collections: make(map[string][]types.Record)}
+}
+
+func collectionKey(schemaName, collectionName string) string {
+	return schemaName + "/" + collectionName
+}
+
+func isReservedField(k string) bool {
+	switch k {
+	case "_id", "createdAt", "updatedAt", "_version", "_locked_by", "_lock_expires_at":
+		return true
+	}
+	return false
+}
+
+func userFields(record types.Record) types.Record {
+	fields := make(types.Record, len(record))
+	for k, v := range record {
+		if !isReservedField(k) {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+func parseJSON(jsonData string) (map[string]interface{}, error) {
+	var inputData map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.Trim(jsonData, "'\"")), &inputData); err != nil {
+		return nil, kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
+	}
+	return inputData, nil
+}
+
+func newRecord(inputData map[string]interface{}) types.Record {
+	now := time.Now().UTC().Format(time.RFC3339)
+	record := types.Record{
+		"_id":       kid.GenerateID(),
+		"createdAt": now,
+		"updatedAt": now,
+		"_version":  float64(0),
+	}
+	for k, v := range inputData {
+		if !isReservedField(k) {
+			record[k] = v
+		}
+	}
+	return record
+}
+
+func copyRecords(records []types.Record) []types.Record {
+	copied := make([]types.Record, len(records))
+	for i, record := range records {
+		copied[i] = make(types.Record, len(record))
+		for k, v := range record {
+			copied[i][k] = v
+		}
+	}
+	return copied
+}
+
+func notFound(collectionName string) error {
+	return kerrors.New(kerrors.ErrCollectionNotFound, "failed to read collection file: collection %s does not exist", collectionName)
+}
+
+// Seed replaces a collection's records, creating the collection if needed.
+func (db *FakeDB) Seed(schemaName, collectionName string, records []types.Record) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.collections[collectionKey(schemaName, collectionName)] = copyRecords(records)
+}
+
+// Snapshot returns a copy of a collection's records, or nil if it does not
+// exist.
+func (db *FakeDB) Snapshot(schemaName, collectionName string) []types.Record {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	records, ok := db.collections[collectionKey(schemaName, collectionName)]
+	if !ok {
+		return nil
+	}
+	return copyRecords(records)
+}
+
+func (db *FakeDB) AddCollection(collectionName, schemaName, jsonData string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.addCollection(collectionName, schemaName, jsonData)
+}
+
+func (db *FakeDB) addCollection(collectionName, schemaName, jsonData string) error {
+	key := collectionKey(schemaName, collectionName)
+	if _, ok := db.collections[key]; ok {
+		return kerrors.New(kerrors.ErrCollectionExists, "collection %s already exists in %s", collectionName, filepath.Join("..", "db", schemaName))
+	}
+
+	// jsonData is empty, a single initial record, or an array of them.
+	records := []types.Record{}
+	if cleanedJSON := strings.TrimSpace(strings.Trim(jsonData, "'\"")); strings.HasPrefix(cleanedJSON, "[") {
+		var inputs []map[string]interface{}
+		if err := json.Unmarshal([]byte(cleanedJSON), &inputs); err != nil {
+			return kerrors.New(kerrors.ErrInvalidJSON, "failed to parse JSON data: %v", err)
+		}
+		for _, inputData := range inputs {
+			records = append(records, newRecord(inputData))
+		}
+	} else if cleanedJSON != "" {
+		inputData, err := parseJSON(jsonData)
+		if err != nil {
+			return err
+		}
+		records = append(records, newRecord(inputData))
+	}
+	db.collections[key] = records
+	return nil
+}
+
+func (db *FakeDB) InsertRecord(ctx context.Context, collectionName, jsonData, schemaName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := collectionKey(schemaName, collectionName)
+	if _, ok := db.collections[key]; !ok {
+		return db.addCollection(collectionName, schemaName, jsonData)
+	}
+	inputData, err := parseJSON(jsonData)
+	if err != nil {
+		return err
+	}
+	db.collections[key] = append(db.collections[key], newRecord(inputData))
+	return nil
+}
+
+func (db *FakeDB) EditCollection(ctx context.Context, collectionName, id, jsonData, schemaName, identity string) ([]types.FieldChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	records, ok := db.collections[collectionKey(schemaName, collectionName)]
+	if !ok {
+		return nil, notFound(collectionName)
+	}
+	inputData, err := parseJSON(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		if record["_id"] != id {
+			continue
+		}
+		version, err := helper.ToFloat64(record["_version"])
+		if err != nil {
+			return nil, fmt.Errorf("record %s has an invalid _version: %v", id, err)
+		}
+		updated := types.Record{
+			"_id":       id,
+			"createdAt": record["createdAt"],
+			"updatedAt": time.Now().UTC().Format(time.RFC3339),
+			"_version":  version + 1,
+		}
+		if owner, ok := record["_locked_by"]; ok {
+			updated["_locked_by"] = owner
+			updated["_lock_expires_at"] = record["_lock_expires_at"]
+		}
+		for k, v := range inputData {
+			if !isReservedField(k) {
+				updated[k] = v
+			}
+		}
+		records[i] = updated
+		return kdiff.RecordDiff(userFields(record), userFields(updated)), nil
+	}
+	return nil, kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
+}
+
+func (db *FakeDB) MoveRecord(ctx context.Context, collectionName, id, schemaName, identity string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := collectionKey(schemaName, collectionName)
+	records, ok := db.collections[key]
+	if !ok {
+		return notFound(collectionName)
+	}
+	for i, record := range records {
+		if record["_id"] == id {
+			db.collections[key] = append(records[:i], records[i+1:]...)
+			return nil
+		}
+	}
+	return kerrors.New(kerrors.ErrRecordNotFound, "record with _id %s not found", id)
+}
+
+// PullCollection prints the collection the way controller.PullCollection
+// does.
+func (db *FakeDB) PullCollection(ctx context.Context, collectionName, schemaName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	records, ok := db.collections[collectionKey(schemaName, collectionName)]
+	if !ok {
+		return notFound(collectionName)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format JSON: %v", err)
+	}
+	fmt.Printf("Collection %s contents:\n%s\n", collectionName, data)
+	return nil
+}
@@ -0,0 +1,142 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"kite/src/controller"
+	kerrors "kite/src/errors"
+	kid "kite/src/id"
+	"kite/src/types"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// step is one operation run against both FakeDB and the controller. target
+// picks the record an edit or delete applies to by position, since the two
+// generate different _ids; -1 names a record that does not exist.
+type step struct {
+	op, collection, data string
+	target               int
+}
+
+// parityDB is one of the implementations under comparison, with a way to
+// read a collection back.
+type parityDB struct {
+	name string
+	ops  types.DBOperations
+	read func(collectionName string) ([]types.Record, error)
+}
+
+func TestFakeDBMatchesController(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64}
+	controller.Configure(cfg)
+	fake := NewFakeDB()
+	dbs := []parityDB{
+		{"controller", controller.NewStore(cfg), func(collectionName string) ([]types.Record, error) {
+			return controller.ReadCollection(collectionName, "public")
+		}},
+		{"fake", fake, func(collectionName string) ([]types.Record, error) {
+			if records := fake.Snapshot("public", collectionName); records != nil {
+				return records, nil
+			}
+			return nil, notFound(collectionName)
+		}},
+	}
+
+	steps := []step{
+		{op: "add", collection: "notes", data: `{"title":"a"}`},
+		{op: "add", collection: "notes", data: `{"title":"again"}`},
+		{op: "add", collection: "list", data: `[{"title":"x"},{"title":"y","n":2}]`},
+		{op: "add", collection: "empty"},
+		{op: "add", collection: "bad", data: `{"title":`},
+		{op: "insert", collection: "notes", data: `{"title":"b","n":1}`},
+		{op: "insert", collection: "notes", data: `{"_id":"mine","_version":9,"title":"c"}`},
+		{op: "insert", collection: "notes", data: `not json`},
+		{op: "insert", collection: "fresh", data: `{"title":"d"}`},
+		{op: "edit", collection: "notes", data: `{"title":"b2","tags":["t"]}`, target: 1},
+		{op: "edit", collection: "notes", data: `{"title":"e"}`, target: -1},
+		{op: "edit", collection: "missing", data: `{"title":"e"}`, target: -1},
+		{op: "edit", collection: "notes", data: `{"title":`, target: 0},
+		{op: "delete", collection: "notes", target: 0},
+		{op: "delete", collection: "notes", target: -1},
+		{op: "delete", collection: "missing", target: -1},
+		{op: "pull", collection: "missing"},
+	}
+
+	for i, s := range steps {
+		var results [2]struct {
+			code    string
+			changes []types.FieldChange
+		}
+		for j, db := range dbs {
+			code, changes := runStep(t, db, s)
+			results[j].code, results[j].changes = code, changes
+		}
+		if results[0].code != results[1].code {
+			t.Errorf("step %d %s %s: controller error %q, fake error %q", i, s.op, s.collection, results[0].code, results[1].code)
+		}
+		if diff := cmp.Diff(results[0].changes, results[1].changes); diff != "" {
+			t.Errorf("step %d %s %s: changes differ (-controller +fake):\n%s", i, s.op, s.collection, diff)
+		}
+	}
+
+	for _, collectionName := range []string{"notes", "list", "empty", "fresh", "bad"} {
+		var states [2][]map[string]interface{}
+		for j, db := range dbs {
+			records, err := db.read(collectionName)
+			if err != nil && kerrors.Code(err) != kerrors.ErrCollectionNotFound {
+				t.Fatalf("%s: reading %s: %v", db.name, collectionName, err)
+			}
+			states[j] = comparable(t, records)
+		}
+		if diff := cmp.Diff(states[0], states[1]); diff != "" {
+			t.Errorf("collection %s differs (-controller +fake):\n%s", collectionName, diff)
+		}
+	}
+}
+
+// runStep applies s to db and returns the error code, if any, and the
+// changes reported by an edit.
+func runStep(t *testing.T, db parityDB, s step) (string, []types.FieldChange) {
+	t.Helper()
+	ctx := context.Background()
+	id := kid.GenerateID()
+	if s.op == "edit" || s.op == "delete" {
+		if records, err := db.read(s.collection); err == nil && s.target >= 0 && s.target < len(records) {
+			id = records[s.target]["_id"].(string)
+		}
+	}
+
+	var err error
+	var changes []types.FieldChange
+	switch s.op {
+	case "add":
+		err = db.ops.AddCollection(s.collection, "public", s.data)
+	case "insert":
+		err = db.ops.InsertRecord(ctx, s.collection, s.data, "public")
+	case "edit":
+		changes, err = db.ops.EditCollection(ctx, s.collection, id, s.data, "public", "")
+	case "delete":
+		err = db.ops.MoveRecord(ctx, s.collection, id, "public", "")
+	case "pull":
+		err = db.ops.PullCollection(ctx, s.collection, "public")
+	}
+	if err != nil {
+		return kerrors.Code(err), changes
+	}
+	return "", changes
+}
+
+// comparable strips the generated metadata from records, keeping _version,
+// and normalizes their values through JSON.
+func comparable(t *testing.T, records []types.Record) []map[string]interface{} {
+	t.Helper()
+	out := normalize(t, records)
+	for _, record := range out {
+		delete(record, "_id")
+		delete(record, "createdAt")
+		delete(record, "updatedAt")
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	kerrors "kite/src/errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Roles a user can be given. An admin may use every schema; the others are
+// limited to their own, and readonly to GET requests.
+const (
+	RoleAdmin     = "admin"
+	RoleReadWrite = "readwrite"
+	RoleReadOnly  = "readonly"
+)
+
+// DefaultSessionTTL is how long a login token is valid.
+const DefaultSessionTTL = 24 * time.Hour
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleReadWrite, RoleReadOnly:
+		return true
+	}
+	return false
+}
+
+// SessionClaims identify a logged-in user. The subject is the username.
+type SessionClaims struct {
+	SchemaName string `json:"schema_name"`
+	Role       string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// IssueSession signs a login token for username with secret.
+func IssueSession(secret, username, schemaName, role string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl).Truncate(time.Second)
+	claims := SessionClaims{
+		SchemaName: schemaName,
+		Role:       role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   username,
+			Audience:  jwt.ClaimStrings{SessionAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %v", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// ValidateSession checks the signature and expiry of a login token and
+// returns its claims.
+func ValidateSession(secret, tokenString string) (*SessionClaims, error) {
+	var claims SessionClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired(), jwt.WithAudience(SessionAudience))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, kerrors.New(kerrors.ErrUnauthorized, "token has expired")
+	}
+	if err != nil {
+		return nil, kerrors.New(kerrors.ErrUnauthorized, "invalid token: %v", err)
+	}
+	return &claims, nil
+}
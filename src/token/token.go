@@ -0,0 +1,221 @@
+package token
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"kite/src/controller"
+	kerrors "kite/src/errors"
+	"kite/src/helper"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fileMu guards the secret and blocklist files within this process.
+var fileMu sync.Mutex
+
+func systemDir() string {
+	return filepath.Join(controller.DBPath(), controller.SystemSchema)
+}
+
+func secretPath() string {
+	return filepath.Join(systemDir(), "token.key")
+}
+
+func revokedPath() string {
+	return filepath.Join(systemDir(), "revoked_tokens.txt")
+}
+
+// Audiences tell the two kinds of token apart: collection scoped tokens
+// from Generate and login tokens from IssueSession.
+const (
+	ScopedAudience  = "kite-collection"
+	SessionAudience = "kite-session"
+)
+
+// IsScoped reports whether tokenString claims to be a collection scoped
+// token. It does not check the signature; Validate does.
+func IsScoped(tokenString string) bool {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &claims); err != nil {
+		return false
+	}
+	return slices.Contains(claims.Audience, ScopedAudience)
+}
+
+// Claims scope a token to one collection. The token ID identifies its holder
+// in ACLs, record locks and audit entries.
+type Claims struct {
+	Schema      string   `json:"schema"`
+	Collection  string   `json:"collection"`
+	Permissions []string `json:"permissions"`
+	jwt.RegisteredClaims
+}
+
+// Allows reports whether the token grants permission.
+func (c *Claims) Allows(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+// secret returns the signing key, generating it on first use.
+func secret() ([]byte, error) {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	key, err := os.ReadFile(secretPath())
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read token key: %v", err)
+	}
+
+	if err := os.MkdirAll(systemDir(), controller.DirMode()); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %v", systemDir(), err)
+	}
+	key, err = helper.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token key: %v", err)
+	}
+	if err := os.WriteFile(secretPath(), key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write token key: %v", err)
+	}
+	return key, nil
+}
+
+// Generate signs a token granting permissions on one collection until ttl
+// has passed.
+func Generate(schemaName, collectionName string, permissions []string, ttl time.Duration) (string, time.Time, error) {
+	if len(permissions) == 0 {
+		return "", time.Time{}, kerrors.New(kerrors.ErrInvalidRequest, "at least one permission is required")
+	}
+	for _, p := range permissions {
+		if !controller.ValidPermission(p) {
+			return "", time.Time{}, kerrors.New(kerrors.ErrInvalidRequest, "unknown permission %q", p)
+		}
+	}
+	if ttl <= 0 {
+		return "", time.Time{}, kerrors.New(kerrors.ErrInvalidRequest, "token lifetime must be positive")
+	}
+
+	key, err := secret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token ID: %v", err)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl).Truncate(time.Second)
+	claims := Claims{
+		Schema:      schemaName,
+		Collection:  collectionName,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(id),
+			Audience:  jwt.ClaimStrings{ScopedAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %v", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// Validate checks the signature, expiry and blocklist of a token and
+// returns its claims.
+func Validate(tokenString string) (*Claims, error) {
+	key, err := secret()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	_, err = jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired(), jwt.WithAudience(ScopedAudience))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, kerrors.New(kerrors.ErrUnauthorized, "token has expired")
+	}
+	if err != nil {
+		return nil, kerrors.New(kerrors.ErrUnauthorized, "invalid token: %v", err)
+	}
+
+	revoked, err := IsRevoked(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, kerrors.New(kerrors.ErrUnauthorized, "token has been revoked")
+	}
+	return &claims, nil
+}
+
+// fingerprint is what the blocklist stores instead of the token itself.
+func fingerprint(tokenString string) string {
+	sum := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(sum[:])
+}
+
+// Revoke adds a token to the blocklist.
+func Revoke(tokenString string) error {
+	revoked, err := IsRevoked(tokenString)
+	if err != nil || revoked {
+		return err
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	if err := os.MkdirAll(systemDir(), controller.DirMode()); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", systemDir(), err)
+	}
+	f, err := os.OpenFile(revokedPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open token blocklist: %v", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, fingerprint(tokenString)); err != nil {
+		return fmt.Errorf("failed to write token blocklist: %v", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether a token is on the blocklist.
+func IsRevoked(tokenString string) (bool, error) {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	f, err := os.Open(revokedPath())
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read token blocklist: %v", err)
+	}
+	defer f.Close()
+
+	want := fingerprint(tokenString)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == want {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
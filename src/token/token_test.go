@@ -0,0 +1,61 @@
+package token
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"kite/src/controller"
+	"kite/src/types"
+)
+
+func TestTokenKindsAreDistinct(t *testing.T) {
+	controller.NewStore(types.DBConfig{DBPath: t.TempDir()})
+
+	scoped, _, err := Generate("public", "notes", []string{controller.PermRead}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := os.ReadFile(secretPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A login token signed with the same key must still not pass as a
+	// scoped token, nor the other way round.
+	session, _, err := IssueSession(string(key), "ann", "public", RoleAdmin, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsScoped(scoped) || IsScoped(session) {
+		t.Errorf("IsScoped(scoped) = %v, IsScoped(session) = %v", IsScoped(scoped), IsScoped(session))
+	}
+	claims, err := Validate(scoped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID == "" {
+		t.Error("scoped token has no ID")
+	}
+	if _, err := Validate(session); err == nil {
+		t.Error("Validate accepted a login token")
+	}
+	if _, err := ValidateSession(string(key), scoped); err == nil {
+		t.Error("ValidateSession accepted a scoped token")
+	}
+}
+
+func TestRevokedTokenRejected(t *testing.T) {
+	controller.NewStore(types.DBConfig{DBPath: t.TempDir()})
+
+	signed, _, err := Generate("public", "notes", []string{controller.PermRead}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Revoke(signed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Validate(signed); err == nil {
+		t.Error("Validate accepted a revoked token")
+	}
+}
@@ -0,0 +1,112 @@
+package ttl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"kite/src/controller"
+	"kite/src/types"
+)
+
+// ParseExpiry accepts either a full RFC3339 timestamp or a plain date.
+func ParseExpiry(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q: expected RFC3339 timestamp or YYYY-MM-DD", value)
+	}
+	return t.UTC(), nil
+}
+
+func SetExpiry(collectionName, schemaName string, expiresAt time.Time) error {
+	return controller.UpdateMeta(collectionName, schemaName, func(meta *types.CollectionMeta) {
+		meta.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	})
+}
+
+func ClearExpiry(collectionName, schemaName string) error {
+	return controller.UpdateMeta(collectionName, schemaName, func(meta *types.CollectionMeta) {
+		meta.ExpiresAt = ""
+	})
+}
+
+func IsExpired(collectionName, schemaName string) (bool, error) {
+	meta, err := controller.ReadMeta(collectionName, schemaName)
+	if err != nil {
+		return false, err
+	}
+	if meta.ExpiresAt == "" {
+		return false, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, meta.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("invalid expires_at in meta file: %v", err)
+	}
+	return !time.Now().Before(expiresAt), nil
+}
+
+// DropExpired drops every expired collection in the root and all schemas and
+// returns them as "schema/collection". Expiry does not override read-only
+// mode: a frozen collection is kept, with a notice, until it is unfrozen. A
+// collection that cannot be dropped is reported and skipped, so that it does
+// not hold up the rest; the failures are returned joined.
+func DropExpired() ([]string, error) {
+	schemas, err := controller.ListSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []string
+	var errs []error
+	for _, schemaName := range append([]string{""}, schemas...) {
+		collections, err := controller.ListCollections(schemaName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping expiry check for schema %s: %v\n", schemaName, err)
+			errs = append(errs, err)
+			continue
+		}
+		for _, collectionName := range collections {
+			expired, err := IsExpired(collectionName, schemaName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping expiry check for %s/%s: %v\n", schemaName, collectionName, err)
+				continue
+			}
+			if !expired {
+				continue
+			}
+			if readOnly, err := controller.IsReadOnly(collectionName, schemaName); err == nil && readOnly {
+				fmt.Fprintf(os.Stderr, "Keeping expired collection %s/%s: it is read-only\n", schemaName, collectionName)
+				continue
+			}
+			if err := controller.DropCollection(collectionName, schemaName); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to drop expired collection %s/%s: %v\n", schemaName, collectionName, err)
+				errs = append(errs, fmt.Errorf("%s/%s: %w", schemaName, collectionName, err))
+				continue
+			}
+			dropped = append(dropped, schemaName+"/"+collectionName)
+		}
+	}
+	return dropped, errors.Join(errs...)
+}
+
+// StartExpiryWorker drops expired collections now and then once per interval.
+func StartExpiryWorker(interval time.Duration) {
+	run := func() {
+		if _, err := DropExpired(); err != nil {
+			fmt.Fprintf(os.Stderr, "Collection expiry check failed: %v\n", err)
+		}
+	}
+
+	run()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+package ttl
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"kite/src/controller"
+	"kite/src/types"
+)
+
+func TestDropExpiredKeepsReadOnlyAndGoesOn(t *testing.T) {
+	cfg := types.DBConfig{DBPath: t.TempDir(), CacheCapacity: 64}
+	controller.Configure(cfg)
+	controller.NewStore(cfg)
+	if err := controller.EnsureSchema("public", ""); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	for _, name := range []string{"archive", "scratch"} {
+		if err := controller.AddCollectionAt(name, "public", "", `[{"title":"a"}]`); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetExpiry(name, "public", past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := controller.SetReadOnly("archive", "public", true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	dropped, err := DropExpired()
+	if err != nil {
+		t.Fatalf("DropExpired: %v", err)
+	}
+	if !slices.Equal(dropped, []string{"public/scratch"}) {
+		t.Errorf("dropped = %v, want [public/scratch]", dropped)
+	}
+	collections, err := controller.ListCollections("public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(collections, []string{"archive"}) {
+		t.Errorf("collections left = %v, want [archive]", collections)
+	}
+
+	// Unfrozen, the collection goes on the next sweep.
+	if err := controller.SetReadOnly("archive", "public", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if dropped, err := DropExpired(); err != nil || !slices.Equal(dropped, []string{"public/archive"}) {
+		t.Errorf("second sweep = %v, %v, want [public/archive]", dropped, err)
+	}
+}
@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+type AuditLogMeta struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	SizeBytes int64     `json:"size_bytes"`
+	RotatedAt time.Time `json:"rotated_at"`
+}
+
+// AuditEntry is one line of a schema's audit log.
+type AuditEntry struct {
+	Time         time.Time         `json:"time"`
+	Operation    string            `json:"operation"`
+	Collection   string            `json:"collection"`
+	RecordID     string            `json:"record_id,omitempty"`
+	Actor        string            `json:"actor,omitempty"`
+	Result       string            `json:"result"`
+	ErrorMessage string            `json:"error_message,omitempty"`
+	Details      map[string]string `json:"details,omitempty"`
+}
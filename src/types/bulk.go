@@ -0,0 +1,7 @@
+package types
+
+type BulkError struct {
+	Index  int                    `json:"index"`
+	Record map[string]interface{} `json:"record,omitempty"`
+	Error  string                 `json:"error"`
+}
@@ -0,0 +1,9 @@
+package types
+
+type LeaderInfo struct {
+	InstanceID string `json:"instance_id"`
+	Host       string `json:"host"`
+	URL        string `json:"url,omitempty"`
+	ElectedAt  string `json:"elected_at"`
+	Heartbeat  string `json:"heartbeat"`
+}
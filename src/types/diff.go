@@ -0,0 +1,20 @@
+package types
+
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+	Op     string      `json:"op"`
+}
+
+type RecordDiff struct {
+	ID      string        `json:"_id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+type CollectionDiff struct {
+	Added     []Record     `json:"added"`
+	Removed   []Record     `json:"removed"`
+	Modified  []RecordDiff `json:"modified"`
+	Unchanged int          `json:"unchanged"`
+}
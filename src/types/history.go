@@ -0,0 +1,8 @@
+package types
+
+type Snapshot struct {
+	Timestamp   string `json:"timestamp"`
+	Operation   string `json:"operation"`
+	RecordCount int    `json:"record_count"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
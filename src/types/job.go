@@ -0,0 +1,13 @@
+package types
+
+type JobStatus struct {
+	JobID           string `json:"job_id"`
+	Owner           string `json:"owner"`
+	Status          string `json:"status"`
+	Inserted        int    `json:"inserted"`
+	Total           int    `json:"total"`
+	ProgressPercent int    `json:"progress_percent"`
+	Error           string `json:"error,omitempty"`
+	StartedAt       string `json:"started_at"`
+	FinishedAt      string `json:"finished_at,omitempty"`
+}
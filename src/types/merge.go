@@ -0,0 +1,14 @@
+package types
+
+type MergeStrategy string
+
+const (
+	MergeStrategyOverwrite MergeStrategy = "overwrite"
+	MergeStrategyPreserve  MergeStrategy = "preserve"
+	MergeStrategyError     MergeStrategy = "error"
+)
+
+type MergeOptions struct {
+	Strategy       MergeStrategy `json:"strategy"`
+	ConflictFields []string      `json:"conflict_fields,omitempty"`
+}
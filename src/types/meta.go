@@ -0,0 +1,46 @@
+package types
+
+// CollectionMeta is stored next to a collection as <collection>.meta.json.
+type CollectionMeta struct {
+	CreatedAt string `json:"created_at,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+
+	// Set on a branch created by kite fork.
+	ForkedFrom string `json:"forked_from,omitempty"`
+	ForkBranch string `json:"fork_branch,omitempty"`
+	ForkedAt   string `json:"forked_at,omitempty"`
+
+	// Set on a collection that has been forked.
+	Forks []ForkInfo `json:"forks,omitempty"`
+
+	ACL []ACLEntry `json:"acl,omitempty"`
+
+	LastAccessedAt string `json:"last_accessed_at,omitempty"`
+
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	Tags []string `json:"tags,omitempty"`
+}
+
+type CollectionSummary struct {
+	Name   string   `json:"name"`
+	Schema string   `json:"schema"`
+	Tags   []string `json:"tags"`
+}
+
+type ACLEntry struct {
+	Identity    string   `json:"identity"`
+	Permissions []string `json:"permissions"`
+}
+
+// SchemaACL is stored in a schema directory's .acl.json and maps identities
+// to the permissions they hold on every collection in the schema.
+type SchemaACL struct {
+	Identities map[string][]string `json:"identities"`
+}
+
+type ForkInfo struct {
+	ForkedFrom string `json:"forked_from"`
+	ForkBranch string `json:"fork_branch"`
+	ForkedAt   string `json:"forked_at"`
+}
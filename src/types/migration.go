@@ -0,0 +1,12 @@
+package types
+
+type SchemaMigration struct {
+	Rename map[string]string      `json:"rename,omitempty"`
+	Add    map[string]interface{} `json:"add,omitempty"`
+	Remove []string               `json:"remove,omitempty"`
+}
+
+type MigrationPreview struct {
+	Before Record `json:"before"`
+	After  Record `json:"after"`
+}
@@ -0,0 +1,14 @@
+package types
+
+import "context"
+
+// DBOperations is the set of collection operations exposed by the controller
+// package, so callers can substitute an implementation such as
+// testutil.FakeDB for controller.Store.
+type DBOperations interface {
+	AddCollection(collectionName, schemaName, jsonData string) error
+	InsertRecord(ctx context.Context, collectionName, jsonData, schemaName string) error
+	EditCollection(ctx context.Context, collectionName, id, jsonData, schemaName, identity string) ([]FieldChange, error)
+	MoveRecord(ctx context.Context, collectionName, id, schemaName, identity string) error
+	PullCollection(ctx context.Context, collectionName, schemaName string) error
+}
@@ -0,0 +1,27 @@
+package types
+
+type FilterExpression struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+type QueryPlan struct {
+	TotalRecords      int                `json:"total_records"`
+	FilterApplied     bool               `json:"filter_applied"`
+	FilterExpressions []FilterExpression `json:"filter_expressions"`
+	IndexUsed         string             `json:"index_used"`
+	AfterFilter       int                `json:"after_filter"`
+	AfterSort         string             `json:"after_sort"`
+	AfterPagination   int                `json:"after_pagination"`
+	Plan              []string           `json:"plan"`
+}
+
+// Page is one page of records from a paginated read.
+type Page struct {
+	Data       []Record `json:"data"`
+	Page       int      `json:"page"`
+	Limit      int      `json:"limit"`
+	Total      int      `json:"total"`
+	TotalPages int      `json:"total_pages"`
+}
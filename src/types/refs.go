@@ -0,0 +1,8 @@
+package types
+
+// RefViolation is a record whose reference field names an _id that does not
+// exist in the referenced collection.
+type RefViolation struct {
+	Record   Record `json:"record"`
+	RefValue string `json:"ref_value"`
+}
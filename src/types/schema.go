@@ -0,0 +1,19 @@
+package types
+
+// CollectionSchema is stored next to a collection as <collection>.schema.json
+// and controls how writes to the collection behave.
+type CollectionSchema struct {
+	EventSourced bool `json:"event_sourced,omitempty"`
+	MaxRecords   int  `json:"max_records,omitempty"`
+
+	// EvictionPolicy decides what happens at max_records: "reject" (the
+	// default) fails the insert, "lru" evicts the oldest unpinned record.
+	EvictionPolicy string `json:"eviction_policy,omitempty"`
+
+	// PublicRead lets GET requests through without connection credentials.
+	PublicRead bool `json:"public_read,omitempty"`
+
+	// Path, when set, is an absolute directory holding the collection's
+	// data and key files instead of the schema directory.
+	Path string `json:"path,omitempty"`
+}
@@ -0,0 +1,8 @@
+package types
+
+type StaleCollection struct {
+	Schema         string `json:"schema"`
+	Collection     string `json:"collection"`
+	LastAccessedAt string `json:"last_accessed_at"`
+	DaysIdle       int    `json:"days_idle"`
+}
@@ -0,0 +1,13 @@
+package types
+
+type FullCollectionStats struct {
+	Collection      string `json:"collection"`
+	Schema          string `json:"schema"`
+	Records         int    `json:"records"`
+	SizeBytes       int64  `json:"size_bytes"`
+	CompressedBytes int64  `json:"compressed_bytes"`
+	LastWrite       string `json:"last_write"`
+	LastRead        string `json:"last_read,omitempty"`
+	CreatedAt       string `json:"created_at,omitempty"`
+	Encryption      string `json:"encryption"`
+}
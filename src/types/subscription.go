@@ -0,0 +1,9 @@
+package types
+
+type Subscription struct {
+	ID         string `json:"subscription_id"`
+	Schema     string `json:"schema"`
+	Collection string `json:"collection"`
+	CreatedAt  string `json:"created_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
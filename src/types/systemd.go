@@ -0,0 +1,14 @@
+package types
+
+type SystemdConfig struct {
+	ServiceName string
+	BinaryPath  string
+	// DataDir holds config.json and db/.
+	DataDir string
+	// InstallDir holds templates/ and static/; the service runs from it.
+	InstallDir string
+	User    string
+	Group   string
+	// UserUnit installs a per-user unit instead of a system-wide one.
+	UserUnit bool
+}
@@ -0,0 +1,10 @@
+package types
+
+import "time"
+
+type UndoEntry struct {
+	Op        string    `json:"op"`
+	Before    []byte    `json:"-"`
+	After     []byte    `json:"-"`
+	Timestamp time.Time `json:"timestamp"`
+}
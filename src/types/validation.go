@@ -0,0 +1,6 @@
+package types
+
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
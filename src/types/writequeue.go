@@ -0,0 +1,31 @@
+package types
+
+import "time"
+
+// QueuedWrite is an insert waiting in the write queue.
+type QueuedWrite struct {
+	Collection string
+	Schema     string
+	Data       map[string]interface{}
+	EnqueuedAt time.Time
+	// Attempts counts the failed flushes this write has been through.
+	Attempts int
+}
+
+// QueueFailure is a queued insert that was dropped from the queue without
+// being written.
+type QueueFailure struct {
+	Data     map[string]interface{} `json:"data"`
+	Error    string                 `json:"error"`
+	FailedAt time.Time              `json:"failed_at"`
+}
+
+type QueueStatus struct {
+	PendingWrites    int   `json:"pending_writes"`
+	OldestPendingMs  int64 `json:"oldest_pending_ms"`
+	EstimatedFlushMs int64 `json:"estimated_flush_ms"`
+	// FailedWrites counts every write dropped since the server started;
+	// RecentFailures holds the latest of them.
+	FailedWrites   int            `json:"failed_writes"`
+	RecentFailures []QueueFailure `json:"recent_failures,omitempty"`
+}
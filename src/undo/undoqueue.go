@@ -0,0 +1,105 @@
+package undo
+
+import (
+	"sync"
+
+	"kite/src/types"
+)
+
+const DefaultCapacity = 10
+
+var (
+	mu       sync.Mutex
+	capacity = DefaultCapacity
+	queues   = make(map[string]*ring)
+)
+
+// ring keeps the most recent entries, dropping the oldest when full.
+type ring struct {
+	entries []types.UndoEntry
+	start   int
+	size    int
+}
+
+func (r *ring) push(entry types.UndoEntry) {
+	if len(r.entries) == 0 {
+		return
+	}
+	if r.size < len(r.entries) {
+		r.entries[(r.start+r.size)%len(r.entries)] = entry
+		r.size++
+		return
+	}
+	r.entries[r.start] = entry
+	r.start = (r.start + 1) % len(r.entries)
+}
+
+func (r *ring) pop() (types.UndoEntry, bool) {
+	if r.size == 0 {
+		return types.UndoEntry{}, false
+	}
+	r.size--
+	i := (r.start + r.size) % len(r.entries)
+	entry := r.entries[i]
+	r.entries[i] = types.UndoEntry{}
+	return entry, true
+}
+
+func Key(schemaName, collectionName string) string {
+	return schemaName + "/" + collectionName
+}
+
+// SetCapacity changes the number of entries kept per collection. Existing
+// queues are discarded.
+func SetCapacity(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	capacity = n
+	queues = make(map[string]*ring)
+}
+
+func Push(key string, entry types.UndoEntry) {
+	mu.Lock()
+	defer mu.Unlock()
+	q, ok := queues[key]
+	if !ok {
+		q = &ring{entries: make([]types.UndoEntry, capacity)}
+		queues[key] = q
+	}
+	q.push(entry)
+}
+
+// Pop removes and returns the most recent entry for key.
+func Pop(key string) (types.UndoEntry, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	q, ok := queues[key]
+	if !ok {
+		return types.UndoEntry{}, false
+	}
+	return q.pop()
+}
+
+// History returns the entries for key, most recent first.
+func History(key string) []types.UndoEntry {
+	mu.Lock()
+	defer mu.Unlock()
+	q, ok := queues[key]
+	if !ok {
+		return nil
+	}
+	history := make([]types.UndoEntry, 0, q.size)
+	for i := q.size - 1; i >= 0; i-- {
+		history = append(history, q.entries[(q.start+i)%len(q.entries)])
+	}
+	return history
+}
+
+func Clear(key string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(queues, key)
+}
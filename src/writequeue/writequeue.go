@@ -0,0 +1,195 @@
+package writequeue
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"kite/src/types"
+)
+
+const (
+	DefaultFlushInterval = 50 * time.Millisecond
+
+	// MaxAttempts is how many failed flushes a write survives before it is
+	// dropped and reported as a failure.
+	MaxAttempts = 5
+
+	queueSize      = 1024
+	recentFailures = 20
+)
+
+// FlushFunc writes a batch of queued inserts to one collection. A non-nil err
+// means nothing was written and the batch is queued again. Otherwise
+// rejected, when not nil, holds an error for each write that was refused,
+// such as a record failing validation, and nil for each write that was
+// stored.
+type FlushFunc func(collectionName, schemaName string, writes []types.QueuedWrite) (rejected []error, err error)
+
+// failureLog counts a queue's dropped writes and keeps the latest of them.
+type failureLog struct {
+	count  int
+	recent []types.QueueFailure
+}
+
+var (
+	mu        sync.Mutex
+	queues    = make(map[string][]types.QueuedWrite)
+	failures  = make(map[string]*failureLog)
+	flushFn   FlushFunc
+	interval  time.Duration
+	nextFlush time.Time
+
+	// flushMu keeps two flushes of the same queue from interleaving.
+	flushMu sync.Mutex
+)
+
+// Key identifies a collection's queue.
+func Key(schemaName, collectionName string) string {
+	return schemaName + "/" + collectionName
+}
+
+// Start registers the function that persists batches and drains every queue
+// once per interval.
+func Start(flushInterval time.Duration, flush FlushFunc) {
+	mu.Lock()
+	flushFn = flush
+	interval = flushInterval
+	nextFlush = time.Now().Add(flushInterval)
+	mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			mu.Lock()
+			nextFlush = time.Now().Add(flushInterval)
+			mu.Unlock()
+			FlushAll()
+		}
+	}()
+}
+
+// Enqueue adds write to its collection's queue. A full queue is flushed
+// first rather than growing without bound.
+func Enqueue(write types.QueuedWrite) {
+	collectionKey := Key(write.Schema, write.Collection)
+	write.EnqueuedAt = time.Now()
+
+	mu.Lock()
+	full := len(queues[collectionKey]) >= queueSize
+	mu.Unlock()
+	if full {
+		Flush(collectionKey)
+	}
+
+	mu.Lock()
+	queues[collectionKey] = append(queues[collectionKey], write)
+	mu.Unlock()
+}
+
+// Flush writes everything currently queued for one collection.
+func Flush(collectionKey string) {
+	flushMu.Lock()
+	defer flushMu.Unlock()
+
+	mu.Lock()
+	writes := queues[collectionKey]
+	delete(queues, collectionKey)
+	flush := flushFn
+	mu.Unlock()
+	if len(writes) == 0 || flush == nil {
+		return
+	}
+
+	rejected, err := flush(writes[0].Collection, writes[0].Schema, writes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Write queue flush for %s failed: %v\n", collectionKey, err)
+		requeue(collectionKey, writes, err)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, err := range rejected {
+		if err != nil {
+			recordFailure(collectionKey, writes[i], err)
+		}
+	}
+}
+
+// requeue puts a batch whose flush failed back at the front of its queue,
+// dropping the writes that have used up their attempts.
+func requeue(collectionKey string, writes []types.QueuedWrite, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	retry := make([]types.QueuedWrite, 0, len(writes))
+	for _, write := range writes {
+		write.Attempts++
+		if write.Attempts >= MaxAttempts {
+			recordFailure(collectionKey, write, err)
+			continue
+		}
+		retry = append(retry, write)
+	}
+	if len(retry) > 0 {
+		queues[collectionKey] = append(retry, queues[collectionKey]...)
+	}
+}
+
+// recordFailure notes a dropped write. mu must be held.
+func recordFailure(collectionKey string, write types.QueuedWrite, err error) {
+	log := failures[collectionKey]
+	if log == nil {
+		log = &failureLog{}
+		failures[collectionKey] = log
+	}
+	log.count++
+	log.recent = append(log.recent, types.QueueFailure{Data: write.Data, Error: err.Error(), FailedAt: time.Now()})
+	if len(log.recent) > recentFailures {
+		log.recent = log.recent[len(log.recent)-recentFailures:]
+	}
+}
+
+// FlushAll drains every queue. Call it before shutting down.
+func FlushAll() {
+	for _, k := range Keys() {
+		Flush(k)
+	}
+}
+
+// Keys returns the keys of every queue with pending writes, sorted.
+func Keys() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	keys := make([]string, 0, len(queues))
+	for k := range queues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// QueueStatus reports how many writes are waiting on one collection, when
+// the next scheduled flush is due and which writes were dropped.
+func QueueStatus(collectionKey string) types.QueueStatus {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	writes := queues[collectionKey]
+	status := types.QueueStatus{PendingWrites: len(writes)}
+	if len(writes) > 0 {
+		status.OldestPendingMs = now.Sub(writes[0].EnqueuedAt).Milliseconds()
+	}
+	if interval > 0 {
+		status.EstimatedFlushMs = max(nextFlush.Sub(now).Milliseconds(), 0)
+	}
+	if log := failures[collectionKey]; log != nil {
+		status.FailedWrites = log.count
+		status.RecentFailures = append([]types.QueueFailure(nil), log.recent...)
+	}
+	return status
+}
@@ -0,0 +1,87 @@
+package writequeue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kite/src/types"
+)
+
+func enqueueN(schemaName, collectionName string, n int) {
+	for i := 0; i < n; i++ {
+		Enqueue(types.QueuedWrite{Schema: schemaName, Collection: collectionName, Data: map[string]interface{}{"n": i}})
+	}
+}
+
+func TestFlushRequeuesFailedBatch(t *testing.T) {
+	var written []types.QueuedWrite
+	fail := true
+	Start(time.Hour, func(collectionName, schemaName string, writes []types.QueuedWrite) ([]error, error) {
+		if fail {
+			return nil, errors.New("disk full")
+		}
+		written = append(written, writes...)
+		return nil, nil
+	})
+
+	key := Key("public", "requeue")
+	enqueueN("public", "requeue", 3)
+	Flush(key)
+	if got := QueueStatus(key).PendingWrites; got != 3 {
+		t.Fatalf("pending after failed flush = %d, want 3", got)
+	}
+
+	enqueueN("public", "requeue", 1)
+	fail = false
+	Flush(key)
+	if got := QueueStatus(key); got.PendingWrites != 0 || got.FailedWrites != 0 {
+		t.Fatalf("status after flush = %+v", got)
+	}
+	if len(written) != 4 {
+		t.Fatalf("wrote %d records, want 4", len(written))
+	}
+	for i, write := range written[:3] {
+		if write.Data["n"] != i {
+			t.Errorf("write %d out of order: %v", i, write.Data)
+		}
+	}
+}
+
+func TestFlushDropsAfterMaxAttempts(t *testing.T) {
+	Start(time.Hour, func(collectionName, schemaName string, writes []types.QueuedWrite) ([]error, error) {
+		return nil, errors.New("collection missing")
+	})
+
+	key := Key("public", "dropped")
+	enqueueN("public", "dropped", 2)
+	for i := 0; i < MaxAttempts; i++ {
+		Flush(key)
+	}
+	status := QueueStatus(key)
+	if status.PendingWrites != 0 || status.FailedWrites != 2 {
+		t.Fatalf("status = %+v, want 0 pending and 2 failed", status)
+	}
+	if status.RecentFailures[0].Error != "collection missing" {
+		t.Errorf("failure error = %q", status.RecentFailures[0].Error)
+	}
+}
+
+func TestFlushReportsRejectedWrites(t *testing.T) {
+	Start(time.Hour, func(collectionName, schemaName string, writes []types.QueuedWrite) ([]error, error) {
+		rejected := make([]error, len(writes))
+		rejected[1] = errors.New("missing required field")
+		return rejected, nil
+	})
+
+	key := Key("public", "rejected")
+	enqueueN("public", "rejected", 3)
+	Flush(key)
+	status := QueueStatus(key)
+	if status.PendingWrites != 0 || status.FailedWrites != 1 {
+		t.Fatalf("status = %+v, want 0 pending and 1 failed", status)
+	}
+	if got := status.RecentFailures[0].Data["n"]; got != 1 {
+		t.Errorf("failed write = %v, want n=1", got)
+	}
+}